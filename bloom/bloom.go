@@ -0,0 +1,128 @@
+// Package bloom implements a Bloom filter over 64-bit key hashes with periodic
+// reset, suitable as a doorkeeper that remembers keys recently found absent
+// upstream, so that repeated lookups of them can skip the origin.
+//
+// # False positives
+//
+// A filter created with New(n, p) reports a key it has not seen with
+// probability at most about p, as long as it holds no more than n keys. It
+// never reports a key it has seen as absent, until the next reset.
+//
+// # Reset
+//
+// Once n distinct keys have been added, the filter clears itself. This keeps
+// the false positive rate within the target however many keys pass through,
+// and bounds how long a key stays in the filter, so a key that has since
+// appeared upstream is eventually looked up again.
+package bloom
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+)
+
+// minBits is the smallest filter size, a single word.
+const minBits = 64
+
+// seed decorrelates the two hashes derived from a single key hash.
+const seed = 0x9e3779b97f4a7c15
+
+// Filter is a Bloom filter keyed by 64-bit hashes.
+//
+// Callers hash their keys (with any well-distributed 64-bit hash) and pass the
+// hash to Add and Contains. A Filter is not safe for concurrent use.
+//
+// The zero value is not usable; create filters with New.
+type Filter struct {
+	bits      []uint64
+	mask      uint64
+	hashes    int
+	additions int
+	capacity  int
+}
+
+// New returns a filter holding up to capacity keys between resets with a
+// false positive rate of at most fpRate. A capacity below 1 is treated as 1.
+// New panics if fpRate is not in the open interval (0, 1).
+func New(capacity int, fpRate float64) *Filter {
+	if !(fpRate > 0 && fpRate < 1) {
+		panic(fmt.Sprintf("bloom: false positive rate %v out of range (0, 1)", fpRate))
+	}
+
+	capacity = max(capacity, 1)
+
+	// The optimal size is -n·ln p / ln²2 bits, probed by m/n·ln 2 hashes.
+	// Rounding the size up to a power of two only lowers the rate.
+	m := max(int(math.Ceil(-float64(capacity)*math.Log(fpRate)/(math.Ln2*math.Ln2))), minBits)
+	m = 1 << bits.Len(uint(m-1))
+
+	return &Filter{
+		bits:     make([]uint64, m/64),
+		mask:     uint64(m - 1),
+		hashes:   max(int(math.Round(float64(m)/float64(capacity)*math.Ln2)), 1),
+		capacity: capacity,
+	}
+}
+
+// Add adds h to the filter, resetting it first if it already holds capacity
+// keys. Adding a key the filter already reports does not count towards the
+// capacity.
+func (f *Filter) Add(h uint64) {
+	if f.Contains(h) {
+		return
+	}
+
+	if f.additions >= f.capacity {
+		f.Reset()
+	}
+
+	h1, h2 := f.split(h)
+
+	for i := range f.hashes {
+		idx := (h1 + uint64(i)*h2) & f.mask
+		f.bits[idx/64] |= 1 << (idx % 64)
+	}
+
+	f.additions++
+}
+
+// Contains reports whether h may have been added since the last reset. A
+// false result is certain; a true one is wrong with the false positive rate.
+func (f *Filter) Contains(h uint64) bool {
+	h1, h2 := f.split(h)
+
+	for i := range f.hashes {
+		idx := (h1 + uint64(i)*h2) & f.mask
+		if f.bits[idx/64]&(1<<(idx%64)) == 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Reset removes every key from the filter. It is called automatically by Add
+// and is needed directly only to forget keys early, e.g. after the origin
+// has been bulk-loaded.
+func (f *Filter) Reset() {
+	clear(f.bits)
+	f.additions = 0
+}
+
+// split derives the two hashes of double hashing from h. The second is odd,
+// so that probes cover the whole power-of-two table.
+func (f *Filter) split(h uint64) (uint64, uint64) {
+	return mix(h), mix(h^seed) | 1
+}
+
+// mix is the splitmix64 finalizer.
+func mix(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+
+	return x
+}
@@ -0,0 +1,119 @@
+package bloom
+
+import (
+	"math"
+	"testing"
+)
+
+// hash stands in for a well-distributed 64-bit key hash.
+func hash(i int) uint64 {
+	return mix(uint64(i) + 0x632be59bd9b4e019)
+}
+
+func TestNewPanics(t *testing.T) {
+	t.Parallel()
+
+	for _, rate := range []float64{0, 1, -0.5, 1.5, math.NaN()} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("New(100, %v) did not panic", rate)
+				}
+			}()
+
+			New(100, rate)
+		}()
+	}
+}
+
+func TestNoFalseNegatives(t *testing.T) {
+	t.Parallel()
+
+	f := New(1000, 0.01)
+
+	for i := range 1000 {
+		f.Add(hash(i))
+	}
+
+	for i := range 1000 {
+		if !f.Contains(hash(i)) {
+			t.Fatalf("Contains(key %d): want true after Add, got false", i)
+		}
+	}
+}
+
+func TestFalsePositiveRate(t *testing.T) {
+	t.Parallel()
+
+	const (
+		capacity = 10_000
+		probes   = 100_000
+	)
+
+	for _, rate := range []float64{0.1, 0.01, 0.001} {
+		f := New(capacity, rate)
+
+		for i := range capacity {
+			f.Add(hash(i))
+		}
+
+		positives := 0
+
+		for i := range probes {
+			if f.Contains(hash(capacity + i)) {
+				positives++
+			}
+		}
+
+		// Allow for sampling noise on top of the target.
+		if got := float64(positives) / probes; got > 1.5*rate {
+			t.Errorf("New(%d, %v): false positive rate %.4f at capacity", capacity, rate, got)
+		}
+	}
+}
+
+func TestReset(t *testing.T) {
+	t.Parallel()
+
+	f := New(10, 0.01)
+
+	// Repeated keys do not count towards the capacity.
+	for range 3 {
+		for i := range 10 {
+			f.Add(hash(i))
+		}
+	}
+
+	for i := range 10 {
+		if !f.Contains(hash(i)) {
+			t.Fatalf("Contains(key %d): want true before reaching capacity, got false", i)
+		}
+	}
+
+	// The key past the capacity starts afresh.
+	f.Add(hash(10))
+
+	if !f.Contains(hash(10)) {
+		t.Fatal("Contains(key 10): want true after Add, got false")
+	}
+
+	if f.additions != 1 {
+		t.Errorf("additions after reset: want 1, got %d", f.additions)
+	}
+
+	f.Reset()
+
+	if f.Contains(hash(10)) {
+		t.Error("Contains(key 10): want false after Reset, got true")
+	}
+}
+
+func TestCapacityFloor(t *testing.T) {
+	t.Parallel()
+
+	f := New(0, 0.5)
+
+	if f.capacity != 1 || len(f.bits) != 1 {
+		t.Errorf("New(0, 0.5): want capacity 1 in 1 word, got %d in %d", f.capacity, len(f.bits))
+	}
+}
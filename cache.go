@@ -0,0 +1,78 @@
+// Package cache defines the contract shared by cache implementations.
+//
+// Code that accepts "any cache" depends on Interface rather than on a
+// concrete backend, so in-memory, remote and layered caches can be swapped
+// without touching call sites. Optional capabilities, such as per-entry
+// expiration, are separate interfaces that callers discover with a type
+// assertion.
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrNotFound is returned by Get, possibly wrapped, when the key is not in the
+// cache.
+var ErrNotFound = errors.New("cache: not found")
+
+// ErrExpired is returned by Get, possibly wrapped, when the key was found but
+// its time to live has passed. It matches ErrNotFound, so callers that do not
+// need to tell a stale entry from an absent one can check for that alone.
+var ErrExpired error = expiredError{}
+
+type expiredError struct{}
+
+func (expiredError) Error() string { return "cache: expired" }
+
+func (expiredError) Is(target error) bool { return target == ErrNotFound }
+
+// Getter loads the value of key from the source of truth, e.g. on a cache
+// miss.
+type Getter[K comparable, V any] func(ctx context.Context, key K) (V, error)
+
+// Interface is the method set shared by cache implementations.
+//
+// Implementations must be safe for concurrent use.
+type Interface[K comparable, V any] interface {
+	// Get returns the value stored under key, or an error matching
+	// ErrNotFound if there is none. Implementations that expire entries
+	// should return ErrExpired for an entry that has expired but not yet
+	// been removed.
+	Get(ctx context.Context, key K) (V, error)
+
+	// Set stores value under key, replacing any previous value. If ctx is
+	// done before the value is stored, Set returns ctx.Err().
+	Set(ctx context.Context, key K, value V) error
+
+	// Delete removes key. Deleting an absent key is not an error.
+	Delete(ctx context.Context, key K) error
+
+	// Len returns the number of entries. It may count entries that have
+	// expired but not yet been removed.
+	Len() int
+}
+
+// TTLSetter is implemented by caches that support per-entry expiration.
+type TTLSetter[K comparable, V any] interface {
+	// SetWithTTL stores value under key like Set, expiring it after ttl. A
+	// ttl of zero or less means the entry does not expire.
+	SetWithTTL(ctx context.Context, key K, value V, ttl time.Duration) error
+}
+
+// Clock tells the time to caches that expire entries, so that tests can
+// replace the system clock with one they control.
+type Clock interface {
+	Now() time.Time
+}
+
+// setWithTTL stores value in c, expiring it after ttl if c implements
+// TTLSetter and storing it without expiration otherwise.
+func setWithTTL[K comparable, V any](ctx context.Context, c Interface[K, V], key K, value V, ttl time.Duration) error {
+	if s, ok := c.(TTLSetter[K, V]); ok && ttl > 0 {
+		return s.SetWithTTL(ctx, key, value, ttl)
+	}
+
+	return c.Set(ctx, key, value)
+}
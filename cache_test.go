@@ -0,0 +1,27 @@
+package cache_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"go.expect.digital/cache"
+)
+
+func TestErrExpired(t *testing.T) {
+	t.Parallel()
+
+	wrapped := fmt.Errorf("lookup: %w", cache.ErrExpired)
+
+	if !errors.Is(wrapped, cache.ErrExpired) {
+		t.Error("wrapped ErrExpired does not match ErrExpired")
+	}
+
+	if !errors.Is(wrapped, cache.ErrNotFound) {
+		t.Error("wrapped ErrExpired does not match ErrNotFound")
+	}
+
+	if errors.Is(cache.ErrNotFound, cache.ErrExpired) {
+		t.Error("ErrNotFound matches ErrExpired")
+	}
+}
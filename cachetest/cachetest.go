@@ -0,0 +1,263 @@
+// Package cachetest provides helpers for testing cache implementations and
+// the code that uses them.
+//
+// # Conformance
+//
+// Run checks that an implementation of cache.Interface behaves as the
+// interface documents. Backends call it from their own tests:
+//
+//	func TestConformance(t *testing.T) {
+//		cachetest.Run(t, func(t *testing.T) cache.Interface[string, string] {
+//			return mycache.New[string, string]()
+//		})
+//	}
+//
+// The suite only covers behaviour the interface defines. Capabilities
+// outside of it, such as eviction order or load coalescing, are left to the
+// implementation's own tests.
+//
+// # Time
+//
+// Clock is a cache.Clock that tests move by hand. Fake expires entries
+// against one, and CheckExpiresAfter checks expiration without sleeping.
+package cachetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.expect.digital/cache"
+)
+
+// Factory returns a new, empty cache for a single test. It may use t to
+// register cleanup.
+type Factory func(t *testing.T) cache.Interface[string, string]
+
+// Run runs the conformance tests against caches returned by newCache, each as
+// a parallel subtest of t.
+func Run(t *testing.T, newCache Factory) {
+	t.Helper()
+
+	tests := []struct {
+		name string
+		test func(t *testing.T, c cache.Interface[string, string])
+	}{
+		{"GetMissing", testGetMissing},
+		{"SetGet", testSetGet},
+		{"ZeroValue", testZeroValue},
+		{"Overwrite", testOverwrite},
+		{"Delete", testDelete},
+		{"DeleteMissing", testDeleteMissing},
+		{"Len", testLen},
+		{"TTL", testTTL},
+		{"Concurrent", testConcurrent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tt.test(t, newCache(t))
+		})
+	}
+}
+
+func mustSet(t *testing.T, c cache.Interface[string, string], key, value string) {
+	t.Helper()
+
+	if err := c.Set(context.Background(), key, value); err != nil {
+		t.Fatalf("Set(%q): %v", key, err)
+	}
+}
+
+func checkGet(t *testing.T, c cache.Interface[string, string], key, want string) {
+	t.Helper()
+
+	got, err := c.Get(context.Background(), key)
+	if err != nil {
+		t.Fatalf("Get(%q): want %q, got error %v", key, want, err)
+	}
+
+	if got != want {
+		t.Fatalf("Get(%q): want %q, got %q", key, want, got)
+	}
+}
+
+func checkMissing(t *testing.T, c cache.Interface[string, string], key string) {
+	t.Helper()
+
+	got, err := c.Get(context.Background(), key)
+	if !errors.Is(err, cache.ErrNotFound) {
+		t.Fatalf("Get(%q): want ErrNotFound, got %q, %v", key, got, err)
+	}
+
+	if got != "" {
+		t.Fatalf("Get(%q) of missing key: want zero value, got %q", key, got)
+	}
+}
+
+func checkLen(t *testing.T, c cache.Interface[string, string], want int) {
+	t.Helper()
+
+	if got := c.Len(); got != want {
+		t.Fatalf("Len: want %d, got %d", want, got)
+	}
+}
+
+func testGetMissing(t *testing.T, c cache.Interface[string, string]) {
+	checkMissing(t, c, "a")
+	checkLen(t, c, 0)
+}
+
+func testSetGet(t *testing.T, c cache.Interface[string, string]) {
+	mustSet(t, c, "a", "1")
+	mustSet(t, c, "b", "2")
+
+	checkGet(t, c, "a", "1")
+	checkGet(t, c, "b", "2")
+}
+
+func testZeroValue(t *testing.T, c cache.Interface[string, string]) {
+	// A stored zero value is a hit, not a miss.
+	mustSet(t, c, "a", "")
+	checkGet(t, c, "a", "")
+	checkLen(t, c, 1)
+}
+
+func testOverwrite(t *testing.T, c cache.Interface[string, string]) {
+	mustSet(t, c, "a", "1")
+	mustSet(t, c, "a", "2")
+
+	checkGet(t, c, "a", "2")
+	checkLen(t, c, 1)
+}
+
+func testDelete(t *testing.T, c cache.Interface[string, string]) {
+	mustSet(t, c, "a", "1")
+	mustSet(t, c, "b", "2")
+
+	if err := c.Delete(context.Background(), "a"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	checkMissing(t, c, "a")
+	checkGet(t, c, "b", "2")
+	checkLen(t, c, 1)
+
+	// A deleted key can be stored again.
+	mustSet(t, c, "a", "3")
+	checkGet(t, c, "a", "3")
+}
+
+func testDeleteMissing(t *testing.T, c cache.Interface[string, string]) {
+	if err := c.Delete(context.Background(), "a"); err != nil {
+		t.Fatalf("Delete of missing key: %v", err)
+	}
+
+	checkLen(t, c, 0)
+}
+
+func testLen(t *testing.T, c cache.Interface[string, string]) {
+	for i := range 100 {
+		mustSet(t, c, strconv.Itoa(i), "v")
+	}
+
+	checkLen(t, c, 100)
+}
+
+func testTTL(t *testing.T, c cache.Interface[string, string]) {
+	s, ok := c.(cache.TTLSetter[string, string])
+	if !ok {
+		t.Skip("cache does not implement TTLSetter")
+	}
+
+	ctx := context.Background()
+
+	for key, ttl := range map[string]time.Duration{"hour": time.Hour, "zero": 0, "negative": -time.Hour} {
+		if err := s.SetWithTTL(ctx, key, key, ttl); err != nil {
+			t.Fatalf("SetWithTTL(%q, %v): %v", key, ttl, err)
+		}
+
+		checkGet(t, c, key, key)
+	}
+
+	checkLen(t, c, 3)
+
+	// Set replaces the entry along with its expiration.
+	mustSet(t, c, "hour", "plain")
+	checkGet(t, c, "hour", "plain")
+}
+
+func testConcurrent(t *testing.T, c cache.Interface[string, string]) {
+	const (
+		workers = 8
+		keys    = 16
+		ops     = 500
+	)
+
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+
+	errs := make(chan error, workers)
+
+	for w := range workers {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := range ops {
+				key := strconv.Itoa((w + i) % keys)
+
+				var err error
+
+				switch i % 3 {
+				case 0:
+					err = c.Set(ctx, key, key+"/"+strconv.Itoa(w))
+				case 1:
+					var v string
+
+					// A hit must hold a value written for this key.
+					v, err = c.Get(ctx, key)
+					if err == nil && !strings.HasPrefix(v, key+"/") {
+						err = fmt.Errorf("Get(%q): got %q, written for another key", key, v)
+					} else if errors.Is(err, cache.ErrNotFound) {
+						err = nil
+					}
+				case 2:
+					err = c.Delete(ctx, key)
+				}
+
+				if err != nil {
+					errs <- err
+
+					return
+				}
+
+				_ = c.Len()
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+
+	for i := range keys {
+		key := strconv.Itoa(i)
+		mustSet(t, c, key, key+"/final")
+		checkGet(t, c, key, key+"/final")
+	}
+
+	checkLen(t, c, keys)
+}
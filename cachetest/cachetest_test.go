@@ -0,0 +1,61 @@
+package cachetest_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"go.expect.digital/cache"
+	"go.expect.digital/cache/cachetest"
+)
+
+// mapCache is a minimal conforming implementation.
+type mapCache struct {
+	entries map[string]string
+	mu      sync.Mutex
+}
+
+func (c *mapCache) Get(_ context.Context, key string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	v, ok := c.entries[key]
+	if !ok {
+		return "", cache.ErrNotFound
+	}
+
+	return v, nil
+}
+
+func (c *mapCache) Set(_ context.Context, key, value string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[key] = value
+
+	return nil
+}
+
+func (c *mapCache) Delete(_ context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, key)
+
+	return nil
+}
+
+func (c *mapCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.entries)
+}
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	cachetest.Run(t, func(*testing.T) cache.Interface[string, string] {
+		return &mapCache{entries: make(map[string]string)}
+	})
+}
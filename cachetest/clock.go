@@ -0,0 +1,71 @@
+package cachetest
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"go.expect.digital/cache"
+)
+
+// Clock is a cache.Clock that only moves when told to, so that tests of
+// expiration run instantly and deterministically instead of sleeping.
+//
+// A Clock can be shared by several caches, e.g. the layers of a chain, to
+// move them all at once. Clock is safe for concurrent use.
+type Clock struct {
+	now time.Time
+	mu  sync.Mutex
+}
+
+var _ cache.Clock = (*Clock)(nil)
+
+// NewClock returns a Clock set to start.
+func NewClock(start time.Time) *Clock {
+	return &Clock{now: start}
+}
+
+// Now returns the time of the clock.
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+// Advance moves the clock forward by d.
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+}
+
+// Set sets the clock to t, which may be earlier than its current time.
+func (c *Clock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = t
+}
+
+// CheckExpiresAfter checks that key, just stored in c with value want and a
+// time to live of ttl measured by clock, is still there an instant before
+// ttl has passed and gone once it has. It moves clock forward by ttl.
+func CheckExpiresAfter[K comparable, V comparable](t *testing.T, c cache.Interface[K, V], clock *Clock, key K, want V, ttl time.Duration) {
+	t.Helper()
+
+	clock.Advance(ttl - time.Nanosecond)
+
+	if got, err := c.Get(context.Background(), key); err != nil || got != want {
+		t.Fatalf("Get(%v) before %v: want %v, got %v, %v", key, ttl, want, got, err)
+	}
+
+	clock.Advance(time.Nanosecond)
+
+	if got, err := c.Get(context.Background(), key); !errors.Is(err, cache.ErrNotFound) {
+		t.Fatalf("Get(%v) after %v: want ErrNotFound, got %v, %v", key, ttl, got, err)
+	}
+}
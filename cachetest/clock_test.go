@@ -0,0 +1,83 @@
+package cachetest_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.expect.digital/cache"
+	"go.expect.digital/cache/cachetest"
+)
+
+func TestClock(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, 2, 29, 12, 0, 0, 0, time.UTC)
+	c := cachetest.NewClock(start)
+
+	if !c.Now().Equal(start) {
+		t.Fatalf("Now: want %v, got %v", start, c.Now())
+	}
+
+	c.Advance(90 * time.Minute)
+
+	if want := start.Add(90 * time.Minute); !c.Now().Equal(want) {
+		t.Fatalf("Now after Advance: want %v, got %v", want, c.Now())
+	}
+
+	// Set can move backwards.
+	c.Set(start.Add(-time.Hour))
+
+	if want := start.Add(-time.Hour); !c.Now().Equal(want) {
+		t.Errorf("Now after Set: want %v, got %v", want, c.Now())
+	}
+}
+
+func TestFakeSharedClock(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	clock := cachetest.NewClock(time.Unix(0, 0))
+	f1 := cachetest.NewFake[string, int]().WithClock(clock)
+	f2 := cachetest.NewFake[string, int]().WithClock(clock)
+
+	_ = f1.SetWithTTL(ctx, "a", 1, time.Minute)
+	_ = f2.SetWithTTL(ctx, "a", 1, time.Hour)
+
+	// Advancing one Fake moves the other.
+	f1.Advance(time.Minute)
+
+	if f1.Len() != 0 || f2.Len() != 1 {
+		t.Fatalf("after 1m: want 0 and 1 entries, got %d and %d", f1.Len(), f2.Len())
+	}
+
+	clock.Advance(time.Hour)
+
+	if f2.Len() != 0 {
+		t.Errorf("after 1h1m: want 0 entries, got %d", f2.Len())
+	}
+
+	if f2.Clock() != clock || !f2.Now().Equal(clock.Now()) {
+		t.Error("Fake does not report the clock it was given")
+	}
+}
+
+func TestCheckExpiresAfter(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	clock := cachetest.NewClock(time.Unix(0, 0))
+	c := cache.Chain[string, int](
+		cachetest.NewFake[string, int]().WithClock(clock),
+		cachetest.NewFake[string, int]().WithClock(clock),
+	).WithTTL(0, time.Minute)
+
+	_ = c.SetWithTTL(ctx, "a", 1, 30*time.Second)
+
+	cachetest.CheckExpiresAfter(t, c, clock, "a", 1, 30*time.Second)
+
+	f := cachetest.NewFake[string, int]().WithClock(clock)
+	_ = f.SetWithTTL(ctx, "b", 2, time.Hour)
+
+	cachetest.CheckExpiresAfter(t, f, clock, "b", 2, time.Hour)
+}
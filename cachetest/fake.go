@@ -0,0 +1,261 @@
+package cachetest
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"go.expect.digital/cache"
+)
+
+// Op names a cache operation in a recorded Call.
+type Op string
+
+// Operations recorded by Fake. SetWithTTL is recorded as OpSet.
+const (
+	OpGet    Op = "Get"
+	OpSet    Op = "Set"
+	OpDelete Op = "Delete"
+)
+
+// Call is an operation recorded by Fake.
+type Call[K comparable] struct {
+	Op  Op
+	Key K
+}
+
+type fakeEntry[V any] struct {
+	value    V
+	deadline time.Time // zero if the entry does not expire
+}
+
+type opKey[K comparable] struct {
+	op  Op
+	key K
+}
+
+// Fake is an in-memory cache for testing code that depends on a cache.
+//
+// It records every call, can be told to fail or delay operations on specific
+// keys, and expires entries against a Clock that only moves when told to, so
+// tests of expiration need not sleep.
+//
+// Operations fail with the context's error, without taking effect, if their
+// context is done when they start or while they are delayed.
+//
+// Fake is safe for concurrent use.
+type Fake[K comparable, V any] struct {
+	entries map[K]fakeEntry[V]
+	errs    map[opKey[K]]error
+	delays  map[K]time.Duration
+	calls   []Call[K]
+	clock   *Clock
+	mu      sync.Mutex
+}
+
+var (
+	_ cache.Interface[string, any] = (*Fake[string, any])(nil)
+	_ cache.TTLSetter[string, any] = (*Fake[string, any])(nil)
+)
+
+// NewFake returns an empty Fake with a clock of its own, starting at an
+// arbitrary fixed time.
+func NewFake[K comparable, V any]() *Fake[K, V] {
+	return &Fake[K, V]{
+		entries: make(map[K]fakeEntry[V]),
+		errs:    make(map[opKey[K]]error),
+		delays:  make(map[K]time.Duration),
+		clock:   NewClock(time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)),
+	}
+}
+
+// WithClock makes f expire entries against clock, e.g. one shared with other
+// Fakes, and returns f. It is meant to be called before f is used: deadlines
+// of entries already stored are kept as they are.
+func (f *Fake[K, V]) WithClock(clock *Clock) *Fake[K, V] {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.clock = clock
+
+	return f
+}
+
+// Get returns the value of key, cache.ErrNotFound if it is absent, or
+// cache.ErrExpired the first time it is looked up after expiring.
+func (f *Fake[K, V]) Get(ctx context.Context, key K) (V, error) {
+	var zero V
+
+	if err := f.begin(ctx, OpGet, key); err != nil {
+		return zero, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	e, ok := f.entries[key]
+	if !ok {
+		return zero, cache.ErrNotFound
+	}
+
+	if f.expired(e) {
+		delete(f.entries, key)
+
+		return zero, cache.ErrExpired
+	}
+
+	return e.value, nil
+}
+
+// Set stores value under key without expiration.
+func (f *Fake[K, V]) Set(ctx context.Context, key K, value V) error {
+	return f.SetWithTTL(ctx, key, value, 0)
+}
+
+// SetWithTTL stores value under key, expiring it once the fake clock has
+// moved ttl past the current time. A ttl of zero or less means no
+// expiration.
+func (f *Fake[K, V]) SetWithTTL(ctx context.Context, key K, value V, ttl time.Duration) error {
+	if err := f.begin(ctx, OpSet, key); err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	e := fakeEntry[V]{value: value}
+	if ttl > 0 {
+		e.deadline = f.clock.Now().Add(ttl)
+	}
+
+	f.entries[key] = e
+
+	return nil
+}
+
+// Delete removes key.
+func (f *Fake[K, V]) Delete(ctx context.Context, key K) error {
+	if err := f.begin(ctx, OpDelete, key); err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.entries, key)
+
+	return nil
+}
+
+// Len returns the number of entries that have not expired. It is not
+// recorded as a call.
+func (f *Fake[K, V]) Len() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	n := 0
+
+	for _, e := range f.entries {
+		if !f.expired(e) {
+			n++
+		}
+	}
+
+	return n
+}
+
+// Calls returns the operations made so far, in order.
+func (f *Fake[K, V]) Calls() []Call[K] {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return slices.Clone(f.calls)
+}
+
+// ClearCalls forgets the recorded operations.
+func (f *Fake[K, V]) ClearCalls() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls = nil
+}
+
+// FailOn makes every op on key return err, without otherwise taking effect,
+// until FailOn is called again with a nil err. Failed operations are still
+// recorded.
+func (f *Fake[K, V]) FailOn(op Op, key K, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err == nil {
+		delete(f.errs, opKey[K]{op, key})
+	} else {
+		f.errs[opKey[K]{op, key}] = err
+	}
+}
+
+// Delay makes every operation on key wait for d of real time before taking
+// effect, or until its context is done, in which case the operation returns
+// the context's error. A d of zero or less removes the delay.
+func (f *Fake[K, V]) Delay(key K, d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if d <= 0 {
+		delete(f.delays, key)
+	} else {
+		f.delays[key] = d
+	}
+}
+
+// Clock returns the clock of f.
+func (f *Fake[K, V]) Clock() *Clock {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.clock
+}
+
+// Now returns the time of the clock of f.
+func (f *Fake[K, V]) Now() time.Time {
+	return f.Clock().Now()
+}
+
+// Advance moves the clock of f forward by d, expiring entries whose TTL has
+// passed. It moves every other user of the clock as well.
+func (f *Fake[K, V]) Advance(d time.Duration) {
+	f.Clock().Advance(d)
+}
+
+// begin records a call of op on key, then applies its delay and programmed
+// error. An operation whose ctx is done fails with ctx.Err() without taking
+// effect.
+func (f *Fake[K, V]) begin(ctx context.Context, op Op, key K) error {
+	f.mu.Lock()
+	f.calls = append(f.calls, Call[K]{op, key})
+	delay, err := f.delays[key], f.errs[opKey[K]{op, key}]
+	f.mu.Unlock()
+
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return err
+}
+
+// expired reports whether e has expired. Callers must hold the lock.
+func (f *Fake[K, V]) expired(e fakeEntry[V]) bool {
+	return !e.deadline.IsZero() && !f.clock.Now().Before(e.deadline)
+}
@@ -0,0 +1,168 @@
+package cachetest_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"go.expect.digital/cache"
+	"go.expect.digital/cache/cachetest"
+)
+
+func TestFakeConformance(t *testing.T) {
+	t.Parallel()
+
+	cachetest.Run(t, func(*testing.T) cache.Interface[string, string] {
+		return cachetest.NewFake[string, string]()
+	})
+}
+
+func TestFakeCalls(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	f := cachetest.NewFake[string, int]()
+
+	_ = f.Set(ctx, "a", 1)
+	_, _ = f.Get(ctx, "a")
+	_ = f.SetWithTTL(ctx, "b", 2, time.Minute)
+	_ = f.Delete(ctx, "a")
+	_ = f.Len()
+
+	want := []cachetest.Call[string]{
+		{Op: cachetest.OpSet, Key: "a"},
+		{Op: cachetest.OpGet, Key: "a"},
+		{Op: cachetest.OpSet, Key: "b"},
+		{Op: cachetest.OpDelete, Key: "a"},
+	}
+
+	if got := f.Calls(); !slices.Equal(got, want) {
+		t.Fatalf("Calls: want %v, got %v", want, got)
+	}
+
+	f.ClearCalls()
+
+	if got := f.Calls(); len(got) != 0 {
+		t.Errorf("Calls after ClearCalls: want none, got %v", got)
+	}
+}
+
+func TestFakeFailOn(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	f := cachetest.NewFake[string, int]()
+	errBoom := errors.New("boom")
+
+	f.FailOn(cachetest.OpSet, "a", errBoom)
+
+	if err := f.Set(ctx, "a", 1); !errors.Is(err, errBoom) {
+		t.Fatalf("Set: want errBoom, got %v", err)
+	}
+
+	// The failed Set took no effect, and other keys and ops are unaffected.
+	if _, err := f.Get(ctx, "a"); !errors.Is(err, cache.ErrNotFound) {
+		t.Fatalf("Get after failed Set: want ErrNotFound, got %v", err)
+	}
+
+	if err := f.Set(ctx, "b", 2); err != nil {
+		t.Fatalf("Set of other key: %v", err)
+	}
+
+	f.FailOn(cachetest.OpSet, "a", nil)
+
+	if err := f.Set(ctx, "a", 1); err != nil {
+		t.Fatalf("Set after clearing failure: %v", err)
+	}
+}
+
+func TestFakeDelay(t *testing.T) {
+	t.Parallel()
+
+	f := cachetest.NewFake[string, int]()
+	f.Delay("slow", time.Hour)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := f.Set(ctx, "slow", 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("delayed Set: want DeadlineExceeded, got %v", err)
+	}
+
+	if f.Len() != 0 {
+		t.Fatal("cancelled Set took effect")
+	}
+
+	f.Delay("slow", 0)
+
+	if err := f.Set(context.Background(), "slow", 1); err != nil {
+		t.Fatalf("Set after removing delay: %v", err)
+	}
+}
+
+func TestFakeExpiration(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	f := cachetest.NewFake[string, int]()
+	start := f.Now()
+
+	_ = f.SetWithTTL(ctx, "short", 1, time.Minute)
+	_ = f.SetWithTTL(ctx, "long", 2, time.Hour)
+	_ = f.Set(ctx, "forever", 3)
+
+	f.Advance(time.Minute - time.Nanosecond)
+
+	if _, err := f.Get(ctx, "short"); err != nil {
+		t.Fatalf("Get before TTL: %v", err)
+	}
+
+	f.Advance(time.Nanosecond)
+
+	if _, err := f.Get(ctx, "short"); !errors.Is(err, cache.ErrExpired) {
+		t.Fatalf("Get at TTL: want ErrExpired, got %v", err)
+	}
+
+	// An expired entry is gone once reported.
+	if _, err := f.Get(ctx, "short"); errors.Is(err, cache.ErrExpired) || !errors.Is(err, cache.ErrNotFound) {
+		t.Fatalf("second Get after TTL: want ErrNotFound, got %v", err)
+	}
+
+	if f.Len() != 2 {
+		t.Fatalf("Len: want 2, got %d", f.Len())
+	}
+
+	f.Advance(24 * time.Hour)
+
+	if f.Len() != 1 {
+		t.Fatalf("Len after a day: want 1, got %d", f.Len())
+	}
+
+	if got := f.Now().Sub(start); got != 24*time.Hour+time.Minute {
+		t.Errorf("Now: want start+24h1m, got start+%v", got)
+	}
+}
+
+func TestFakeCancelledContext(t *testing.T) {
+	t.Parallel()
+
+	f := cachetest.NewFake[string, int]()
+	_ = f.Set(context.Background(), "a", 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := f.Set(ctx, "a", 2); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Set: want Canceled, got %v", err)
+	}
+
+	if err := f.Delete(ctx, "a"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Delete: want Canceled, got %v", err)
+	}
+
+	if v, err := f.Get(context.Background(), "a"); err != nil || v != 1 {
+		t.Errorf("Get: want untouched 1, got %d, %v", v, err)
+	}
+}
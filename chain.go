@@ -0,0 +1,138 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Chained is a cache made of layers, created by Chain.
+type Chained[K comparable, V any] struct {
+	layers []Interface[K, V]
+	ttls   []time.Duration
+}
+
+var (
+	_ Interface[string, any] = (*Chained[string, any])(nil)
+	_ TTLSetter[string, any] = (*Chained[string, any])(nil)
+)
+
+// Chain returns a cache that looks keys up in layers in order, typically from
+// the fastest and smallest, such as an in-process cache, to the slowest and
+// largest, such as a remote one. A hit in a deeper layer is written back to
+// every earlier layer. Set and Delete apply to all layers. It panics if no
+// layers are given.
+func Chain[K comparable, V any](layers ...Interface[K, V]) *Chained[K, V] {
+	if len(layers) == 0 {
+		panic("cache: Chain needs at least one layer")
+	}
+
+	return &Chained[K, V]{
+		layers: layers,
+		ttls:   make([]time.Duration, len(layers)),
+	}
+}
+
+// WithTTL caps the TTL of entries written to the layer at index i, by Set or
+// by write-back, at ttl, and returns c. It keeps small, fast layers from
+// holding on to entries as long as the deeper layers do. Layers that do not
+// implement TTLSetter store entries without expiration.
+//
+// WithTTL must be called before c is used. It panics if i is out of range.
+func (c *Chained[K, V]) WithTTL(i int, ttl time.Duration) *Chained[K, V] {
+	c.ttls[i] = ttl
+
+	return c
+}
+
+// Get returns the value from the first layer that has key, writing it back to
+// the layers before it. A layer failing with an error other than ErrNotFound
+// ends the lookup with that error.
+//
+// Write-back is best effort: its errors are ignored, as a layer that missed
+// it only costs a deeper lookup later.
+func (c *Chained[K, V]) Get(ctx context.Context, key K) (V, error) {
+	for i, layer := range c.layers {
+		v, err := layer.Get(ctx, key)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+
+		if err != nil {
+			var zero V
+
+			return zero, err
+		}
+
+		for j := range i {
+			if ctx.Err() != nil {
+				break
+			}
+
+			_ = c.set(ctx, j, key, v, 0)
+		}
+
+		return v, nil
+	}
+
+	var zero V
+
+	return zero, ErrNotFound
+}
+
+// Set stores value in every layer, without expiration other than the layer
+// TTLs set with WithTTL.
+func (c *Chained[K, V]) Set(ctx context.Context, key K, value V) error {
+	return c.SetWithTTL(ctx, key, value, 0)
+}
+
+// SetWithTTL stores value in every layer, expiring it after ttl or the layer
+// TTL, whichever is shorter. Layers are written from the deepest to the
+// first, so an earlier layer never holds a value that deeper ones were not
+// given. The errors of all layers are joined. Once ctx is done, the remaining
+// layers are not written and ctx.Err() is among the errors.
+func (c *Chained[K, V]) SetWithTTL(ctx context.Context, key K, value V, ttl time.Duration) error {
+	var errs []error
+
+	for i := range c.layers {
+		if err := ctx.Err(); err != nil {
+			return errors.Join(append(errs, err)...)
+		}
+
+		errs = append(errs, c.set(ctx, len(c.layers)-1-i, key, value, ttl))
+	}
+
+	return errors.Join(errs...)
+}
+
+// Delete removes key from every layer, from the deepest to the first. The
+// errors of all layers are joined. Once ctx is done, the remaining layers are
+// left alone and ctx.Err() is among the errors.
+func (c *Chained[K, V]) Delete(ctx context.Context, key K) error {
+	var errs []error
+
+	for i := range c.layers {
+		if err := ctx.Err(); err != nil {
+			return errors.Join(append(errs, err)...)
+		}
+
+		errs = append(errs, c.layers[len(c.layers)-1-i].Delete(ctx, key))
+	}
+
+	return errors.Join(errs...)
+}
+
+// Len returns the number of entries in the last layer, which is usually the
+// most complete.
+func (c *Chained[K, V]) Len() int {
+	return c.layers[len(c.layers)-1].Len()
+}
+
+// set stores value in layer i with ttl shortened to the layer TTL.
+func (c *Chained[K, V]) set(ctx context.Context, i int, key K, value V, ttl time.Duration) error {
+	if limit := c.ttls[i]; limit > 0 && (ttl <= 0 || limit < ttl) {
+		ttl = limit
+	}
+
+	return setWithTTL(ctx, c.layers[i], key, value, ttl)
+}
@@ -0,0 +1,203 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.expect.digital/cache"
+	"go.expect.digital/cache/cachetest"
+)
+
+func TestChainConformance(t *testing.T) {
+	t.Parallel()
+
+	cachetest.Run(t, func(*testing.T) cache.Interface[string, string] {
+		return cache.Chain[string, string](
+			cachetest.NewFake[string, string](),
+			cachetest.NewFake[string, string](),
+		)
+	})
+}
+
+func TestChainPanics(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Chain without layers did not panic")
+		}
+	}()
+
+	cache.Chain[string, int]()
+}
+
+func TestChainWriteBack(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	l1, l2, l3 := cachetest.NewFake[string, int](), cachetest.NewFake[string, int](), cachetest.NewFake[string, int]()
+	c := cache.Chain[string, int](l1, l2, l3)
+
+	_ = l3.Set(ctx, "a", 1)
+
+	if v, err := c.Get(ctx, "a"); err != nil || v != 1 {
+		t.Fatalf("Get: want 1, got %d, %v", v, err)
+	}
+
+	for i, l := range []*cachetest.Fake[string, int]{l1, l2} {
+		if v, err := l.Get(ctx, "a"); err != nil || v != 1 {
+			t.Errorf("layer %d after write-back: want 1, got %d, %v", i+1, v, err)
+		}
+	}
+
+	// The next lookup stops at the first layer.
+	l2.ClearCalls()
+	l3.ClearCalls()
+
+	if _, err := c.Get(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(l2.Calls()) != 0 || len(l3.Calls()) != 0 {
+		t.Error("hit in the first layer reached deeper layers")
+	}
+}
+
+func TestChainMiss(t *testing.T) {
+	t.Parallel()
+
+	c := cache.Chain[string, int](cachetest.NewFake[string, int](), cachetest.NewFake[string, int]())
+
+	if _, err := c.Get(context.Background(), "a"); !errors.Is(err, cache.ErrNotFound) {
+		t.Errorf("Get: want ErrNotFound, got %v", err)
+	}
+}
+
+func TestChainLayerError(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	l1, l2 := cachetest.NewFake[string, int](), cachetest.NewFake[string, int]()
+	c := cache.Chain[string, int](l1, l2)
+
+	_ = l2.Set(ctx, "a", 1)
+	l1.FailOn(cachetest.OpGet, "a", errBoom)
+
+	if _, err := c.Get(ctx, "a"); !errors.Is(err, errBoom) {
+		t.Fatalf("Get: want errBoom, got %v", err)
+	}
+
+	// Set and Delete reach every layer despite a failing one.
+	l1.FailOn(cachetest.OpSet, "b", errBoom)
+
+	if err := c.Set(ctx, "b", 2); !errors.Is(err, errBoom) {
+		t.Fatalf("Set: want errBoom, got %v", err)
+	}
+
+	if v, err := l2.Get(ctx, "b"); err != nil || v != 2 {
+		t.Fatalf("last layer after failed Set: want 2, got %d, %v", v, err)
+	}
+
+	l2.FailOn(cachetest.OpDelete, "b", errBoom)
+	_ = l1.Set(ctx, "b", 2)
+
+	if err := c.Delete(ctx, "b"); !errors.Is(err, errBoom) {
+		t.Fatalf("Delete: want errBoom, got %v", err)
+	}
+
+	if l1.Len() != 0 {
+		t.Error("Delete skipped the first layer")
+	}
+}
+
+func TestChainLen(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	l1, l2 := cachetest.NewFake[string, int](), cachetest.NewFake[string, int]()
+	c := cache.Chain[string, int](l1, l2)
+
+	_ = l1.Set(ctx, "a", 1)
+	_ = l2.Set(ctx, "b", 2)
+	_ = l2.Set(ctx, "c", 3)
+
+	if c.Len() != 2 {
+		t.Errorf("Len: want the last layer's 2, got %d", c.Len())
+	}
+}
+
+func TestChainTTL(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	clock := cachetest.NewClock(time.Unix(0, 0))
+	l1 := cachetest.NewFake[string, int]().WithClock(clock)
+	l2 := cachetest.NewFake[string, int]().WithClock(clock)
+	c := cache.Chain[string, int](l1, l2).WithTTL(0, time.Minute)
+
+	_ = c.SetWithTTL(ctx, "short", 1, time.Second)
+	_ = c.SetWithTTL(ctx, "long", 2, time.Hour)
+	_ = c.Set(ctx, "forever", 3)
+
+	clock.Advance(time.Second)
+
+	// The shorter of the two TTLs applies.
+	if l1.Len() != 2 || l2.Len() != 2 {
+		t.Fatalf("after 1s: want 2 entries per layer, got %d and %d", l1.Len(), l2.Len())
+	}
+
+	clock.Advance(time.Minute)
+
+	if l1.Len() != 0 || l2.Len() != 2 {
+		t.Fatalf("after 1m1s: want 0 and 2 entries, got %d and %d", l1.Len(), l2.Len())
+	}
+
+	// Written-back entries get the layer TTL too.
+	if v, err := c.Get(ctx, "forever"); err != nil || v != 3 {
+		t.Fatalf("Get: want 3, got %d, %v", v, err)
+	}
+
+	clock.Advance(time.Minute)
+
+	if l1.Len() != 0 {
+		t.Error("written-back entry outlived the layer TTL")
+	}
+}
+
+func TestChainContext(t *testing.T) {
+	t.Parallel()
+
+	l1, l2 := cachetest.NewFake[string, int](), cachetest.NewFake[string, int]()
+	c := cache.Chain[string, int](l1, l2)
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := c.Set(cancelled, "a", 1); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Set with cancelled ctx: want Canceled, got %v", err)
+	}
+
+	if len(l1.Calls()) != 0 || len(l2.Calls()) != 0 {
+		t.Fatal("Set with cancelled ctx reached a layer")
+	}
+
+	// A deadline passing while a deeper layer is slow stops the chain there.
+	l2.Delay("b", time.Hour)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := c.Set(ctx, "b", 2); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Set past deadline: want DeadlineExceeded, got %v", err)
+	}
+
+	if len(l1.Calls()) != 0 {
+		t.Error("Set past deadline went on to the first layer")
+	}
+
+	if err := c.Delete(cancelled, "b"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Delete with cancelled ctx: want Canceled, got %v", err)
+	}
+}
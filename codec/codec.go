@@ -0,0 +1,109 @@
+// Package codec defines how cached values are turned into bytes and back.
+//
+// A Codec is the shared building block for every feature that needs a
+// value outside of process memory: snapshots, disk and remote tiers, and
+// bytes-backed storage. Gob and JSON implementations are provided; other
+// formats (protobuf, msgpack, ...) only need to satisfy the interface.
+package codec
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ErrNilIndirect is returned by Gob.Marshal for a non-nil pointer that leads
+// to a nil pointer, which gob cannot transmit.
+var ErrNilIndirect = errors.New("codec: gob cannot encode a pointer to a nil pointer")
+
+// Codec marshals values of type V to bytes and unmarshals them back.
+//
+// Implementations must be safe for concurrent use.
+type Codec[V any] interface {
+	Marshal(v V) ([]byte, error)
+	Unmarshal(data []byte) (V, error)
+}
+
+// Gob is a Codec using encoding/gob.
+//
+// Each value is encoded as a self-contained gob stream, so the output can be
+// decoded independently of any other value. Interface-typed values must have
+// their concrete types registered with gob.Register.
+//
+// gob has no representation for a nil pointer, so when V is a pointer type a
+// nil value is encoded as an empty payload and decoded back to nil.
+type Gob[V any] struct{}
+
+// Marshal encodes v with encoding/gob.
+func (Gob[V]) Marshal(v V) ([]byte, error) {
+	for rv, top := reflect.ValueOf(&v).Elem(), true; rv.Kind() == reflect.Pointer; rv, top = rv.Elem(), false {
+		if !rv.IsNil() {
+			continue
+		}
+
+		if top {
+			return []byte{}, nil
+		}
+
+		return nil, ErrNilIndirect
+	}
+
+	var buf bytes.Buffer
+
+	if err := gob.NewEncoder(&buf).Encode(&v); err != nil {
+		return nil, fmt.Errorf("gob encode: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Unmarshal decodes data produced by Marshal. On error it returns the zero V.
+func (Gob[V]) Unmarshal(data []byte) (V, error) {
+	var v V
+
+	if len(data) == 0 && reflect.TypeFor[V]().Kind() == reflect.Pointer {
+		return v, nil
+	}
+
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&v); err != nil {
+		var zero V
+
+		return zero, fmt.Errorf("gob decode: %w", err)
+	}
+
+	return v, nil
+}
+
+// JSON is a Codec using encoding/json.
+type JSON[V any] struct{}
+
+// Marshal encodes v with encoding/json.
+func (JSON[V]) Marshal(v V) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("json encode: %w", err)
+	}
+
+	return data, nil
+}
+
+// Unmarshal decodes data produced by Marshal. On error it returns the zero V.
+func (JSON[V]) Unmarshal(data []byte) (V, error) {
+	var v V
+
+	if err := json.Unmarshal(data, &v); err != nil {
+		var zero V
+
+		return zero, fmt.Errorf("json decode: %w", err)
+	}
+
+	return v, nil
+}
+
+var (
+	_ Codec[any] = Gob[any]{}
+	_ Codec[any] = JSON[any]{}
+)
@@ -0,0 +1,122 @@
+package codec_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"go.expect.digital/cache/codec"
+)
+
+type record struct {
+	Tags  map[string]int
+	Next  *record
+	Name  string
+	Items []string
+	ID    int
+}
+
+func roundTrip[V any](t *testing.T, c codec.Codec[V], v V) {
+	t.Helper()
+
+	data, err := c.Marshal(v)
+	if err != nil {
+		t.Fatalf("Marshal(%#v): %v", v, err)
+	}
+
+	got, err := c.Unmarshal(data)
+	if err != nil {
+		t.Fatalf("Unmarshal(Marshal(%#v)): %v", v, err)
+	}
+
+	if !reflect.DeepEqual(got, v) {
+		t.Errorf("round trip: want %#v, got %#v", v, got)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	n := 42
+	full := record{
+		ID:    1,
+		Name:  "a",
+		Tags:  map[string]int{"x": 1},
+		Items: []string{"b", "c"},
+		Next:  &record{ID: 2},
+	}
+
+	t.Run("gob", func(t *testing.T) {
+		t.Parallel()
+
+		roundTrip(t, codec.Gob[int]{}, 0)
+		roundTrip(t, codec.Gob[int]{}, n)
+		roundTrip(t, codec.Gob[string]{}, "")
+		roundTrip(t, codec.Gob[record]{}, record{})
+		roundTrip(t, codec.Gob[record]{}, full)
+		roundTrip(t, codec.Gob[*record]{}, &full)
+		roundTrip(t, codec.Gob[*int]{}, &n)
+		roundTrip(t, codec.Gob[*int]{}, nil)
+		roundTrip(t, codec.Gob[*record]{}, nil)
+	})
+
+	t.Run("json", func(t *testing.T) {
+		t.Parallel()
+
+		roundTrip(t, codec.JSON[int]{}, 0)
+		roundTrip(t, codec.JSON[int]{}, n)
+		roundTrip(t, codec.JSON[string]{}, "")
+		roundTrip(t, codec.JSON[record]{}, record{})
+		roundTrip(t, codec.JSON[record]{}, full)
+		roundTrip(t, codec.JSON[*record]{}, &full)
+		roundTrip(t, codec.JSON[*int]{}, &n)
+		roundTrip(t, codec.JSON[*int]{}, nil)
+		roundTrip(t, codec.JSON[*record]{}, nil)
+	})
+}
+
+func TestGobNilIndirect(t *testing.T) {
+	t.Parallel()
+
+	if _, err := (codec.Gob[**int]{}).Marshal(new(*int)); !errors.Is(err, codec.ErrNilIndirect) {
+		t.Errorf("want ErrNilIndirect, got %v", err)
+	}
+}
+
+func TestUnmarshalError(t *testing.T) {
+	t.Parallel()
+
+	gobData, err := codec.Gob[record]{}.Marshal(record{ID: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		unmarshal func() (*record, error)
+	}{
+		{"gob garbage", func() (*record, error) { return codec.Gob[*record]{}.Unmarshal([]byte("garbage")) }},
+		{"gob truncated", func() (*record, error) { return codec.Gob[*record]{}.Unmarshal(gobData[:len(gobData)-1]) }},
+		{"json garbage", func() (*record, error) { return codec.JSON[*record]{}.Unmarshal([]byte("{garbage")) }},
+		{"json type mismatch", func() (*record, error) { return codec.JSON[*record]{}.Unmarshal([]byte(`{"ID":"x"}`)) }},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			v, err := test.unmarshal()
+			if err == nil {
+				t.Fatal("want error, got nil")
+			}
+
+			if v != nil {
+				t.Errorf("want zero value on error, got %#v", v)
+			}
+		})
+	}
+
+	if _, err := (codec.Gob[int]{}).Unmarshal(nil); err == nil {
+		t.Error("gob: want error for empty data of non-pointer type, got nil")
+	}
+}
@@ -0,0 +1,238 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"go.expect.digital/cache/expiry"
+)
+
+// expiringBuckets is the number of buckets of the timing wheel of Expiring.
+const expiringBuckets = 1 << 10
+
+// systemClock is the Clock of the system.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+// Expiring is a cache that removes entries of an inner cache at the absolute
+// times set with ExpireAt, e.g. when a token expires or an embargo lifts,
+// independently of any TTL. It is created by NewExpiring.
+//
+// Get reports an entry past its time as expired at once. Removing it from
+// the inner cache is up to Sweep, which Run calls every tick, so that
+// entries that are never looked up again do not linger.
+type Expiring[K comparable, V any] struct {
+	inner     Interface[K, V]
+	clock     Clock
+	wheel     *expiry.Wheel[K]
+	deadlines map[K]time.Time
+	tick      time.Duration
+	mu        sync.Mutex
+}
+
+var (
+	_ Interface[string, any] = (*Expiring[string, any])(nil)
+	_ TTLSetter[string, any] = (*Expiring[string, any])(nil)
+)
+
+// NewExpiring returns a cache that serves entries from inner and removes
+// them at the times set with ExpireAt, sweeping once per tick. It panics if
+// tick is not positive.
+func NewExpiring[K comparable, V any](inner Interface[K, V], tick time.Duration) *Expiring[K, V] {
+	return &Expiring[K, V]{
+		inner:     inner,
+		clock:     systemClock{},
+		wheel:     expiry.New[K](tick, expiringBuckets),
+		deadlines: make(map[K]time.Time),
+		tick:      tick,
+	}
+}
+
+// WithClock makes e tell the time with clock instead of the system clock,
+// e.g. a cachetest.Clock in tests, and returns e. WithClock must be called
+// before e is used.
+func (e *Expiring[K, V]) WithClock(clock Clock) *Expiring[K, V] {
+	e.clock = clock
+
+	return e
+}
+
+// ExpireAt schedules the removal of key at t, replacing any previous
+// schedule. If t has passed, key is deleted right away. The schedule ends
+// with the entry: a later Set, SetWithTTL or Delete of key cancels it.
+func (e *Expiring[K, V]) ExpireAt(ctx context.Context, key K, t time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if !e.clock.Now().Before(t) {
+		return e.Delete(ctx, key)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.wheel.Add(key, t)
+	e.deadlines[key] = t
+
+	return nil
+}
+
+// Get returns the value of key from the inner cache, or ErrExpired if the
+// time set for key with ExpireAt has passed, in which case key is deleted.
+func (e *Expiring[K, V]) Get(ctx context.Context, key K) (V, error) {
+	if e.expired(key) {
+		var zero V
+
+		// Best effort: Sweep retries keys that fail to be deleted.
+		if err := e.inner.Delete(ctx, key); err == nil {
+			e.cancel(key)
+		}
+
+		return zero, ErrExpired
+	}
+
+	return e.inner.Get(ctx, key)
+}
+
+// Set stores value in the inner cache and cancels any ExpireAt of key.
+func (e *Expiring[K, V]) Set(ctx context.Context, key K, value V) error {
+	return e.SetWithTTL(ctx, key, value, 0)
+}
+
+// SetWithTTL stores value in the inner cache, expiring it after ttl if the
+// inner cache implements TTLSetter, and cancels any ExpireAt of key.
+func (e *Expiring[K, V]) SetWithTTL(ctx context.Context, key K, value V, ttl time.Duration) error {
+	if err := setWithTTL(ctx, e.inner, key, value, ttl); err != nil {
+		return err
+	}
+
+	e.cancel(key)
+
+	return nil
+}
+
+// Delete removes key from the inner cache and cancels any ExpireAt of key.
+func (e *Expiring[K, V]) Delete(ctx context.Context, key K) error {
+	if err := e.inner.Delete(ctx, key); err != nil {
+		return err
+	}
+
+	e.cancel(key)
+
+	return nil
+}
+
+// Len returns the number of entries in the inner cache, which may include
+// entries past their time that have not been swept yet.
+func (e *Expiring[K, V]) Len() int {
+	return e.inner.Len()
+}
+
+// Sweep deletes the entries whose time has passed from the inner cache.
+// Entries that fail to be deleted stay scheduled for the next Sweep. The
+// errors are joined; once ctx is done, the remaining entries are left for
+// the next Sweep and ctx.Err() is among the errors.
+//
+// A Set of a key racing with its removal by Sweep may be removed as well.
+func (e *Expiring[K, V]) Sweep(ctx context.Context) error {
+	now := e.clock.Now()
+
+	e.mu.Lock()
+	keys := e.wheel.Advance(now)
+	e.mu.Unlock()
+
+	var errs []error
+
+	for i, key := range keys {
+		if err := ctx.Err(); err != nil {
+			e.retry(keys[i:], now)
+
+			return errors.Join(append(errs, err)...)
+		}
+
+		if !e.due(key) {
+			continue
+		}
+
+		if err := e.inner.Delete(ctx, key); err != nil {
+			e.retry(keys[i:i+1], now)
+			errs = append(errs, err)
+
+			continue
+		}
+
+		e.mu.Lock()
+		if _, scheduled := e.wheel.Deadline(key); !scheduled {
+			delete(e.deadlines, key)
+		}
+		e.mu.Unlock()
+	}
+
+	return errors.Join(errs...)
+}
+
+// Run calls Sweep every tick until ctx is done. It is meant to run in a
+// goroutine of its own for the lifetime of e. Sweep errors are dropped, as
+// the entries concerned are retried on the next tick.
+func (e *Expiring[K, V]) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.tick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			_ = e.Sweep(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// expired reports whether the time set for key has passed.
+func (e *Expiring[K, V]) expired(key K) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	t, ok := e.deadlines[key]
+
+	return ok && !e.clock.Now().Before(t)
+}
+
+// due reports whether key, taken off the wheel by Sweep, is still to be
+// removed, rather than rescheduled or cancelled since.
+func (e *Expiring[K, V]) due(key K) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	_, ok := e.deadlines[key]
+	_, scheduled := e.wheel.Deadline(key)
+
+	return ok && !scheduled
+}
+
+// cancel forgets the time set for key.
+func (e *Expiring[K, V]) cancel(key K) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.wheel.Cancel(key)
+	delete(e.deadlines, key)
+}
+
+// retry schedules keys taken off the wheel by Sweep at now again, unless
+// they were rescheduled or cancelled in the meantime.
+func (e *Expiring[K, V]) retry(keys []K, now time.Time) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, key := range keys {
+		_, ok := e.deadlines[key]
+		if _, scheduled := e.wheel.Deadline(key); ok && !scheduled {
+			e.wheel.Add(key, now)
+		}
+	}
+}
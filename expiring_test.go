@@ -0,0 +1,152 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.expect.digital/cache"
+	"go.expect.digital/cache/cachetest"
+)
+
+func TestExpiringConformance(t *testing.T) {
+	t.Parallel()
+
+	cachetest.Run(t, func(*testing.T) cache.Interface[string, string] {
+		return cache.NewExpiring[string, string](cachetest.NewFake[string, string](), time.Second)
+	})
+}
+
+// newExpiring returns an Expiring over a Fake, both on one cachetest.Clock.
+func newExpiring() (*cache.Expiring[string, int], *cachetest.Fake[string, int], *cachetest.Clock) {
+	clock := cachetest.NewClock(time.Unix(0, 0))
+	inner := cachetest.NewFake[string, int]().WithClock(clock)
+
+	return cache.NewExpiring[string, int](inner, time.Second).WithClock(clock), inner, clock
+}
+
+func TestExpiringExpireAt(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	e, inner, clock := newExpiring()
+
+	_ = e.Set(ctx, "token", 1)
+
+	// The time is exact, not rounded to the tick.
+	if err := e.ExpireAt(ctx, "token", clock.Now().Add(1500*time.Millisecond)); err != nil {
+		t.Fatal(err)
+	}
+
+	clock.Advance(1500*time.Millisecond - time.Nanosecond)
+
+	if v, err := e.Get(ctx, "token"); err != nil || v != 1 {
+		t.Fatalf("Get before its time: want 1, got %d, %v", v, err)
+	}
+
+	clock.Advance(time.Nanosecond)
+
+	if _, err := e.Get(ctx, "token"); !errors.Is(err, cache.ErrExpired) {
+		t.Fatalf("Get at its time: want ErrExpired, got %v", err)
+	}
+
+	if _, err := e.Get(ctx, "token"); errors.Is(err, cache.ErrExpired) || !errors.Is(err, cache.ErrNotFound) {
+		t.Fatalf("second Get: want ErrNotFound, got %v", err)
+	}
+
+	if inner.Len() != 0 {
+		t.Errorf("inner Len: want 0, got %d", inner.Len())
+	}
+
+	// A time that has passed deletes at once.
+	_ = e.Set(ctx, "old", 1)
+
+	if err := e.ExpireAt(ctx, "old", time.Unix(0, 0)); err != nil || inner.Len() != 0 {
+		t.Errorf("ExpireAt in the past: want the entry deleted, got %v and %d entries", err, inner.Len())
+	}
+}
+
+func TestExpiringSweep(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	e, inner, clock := newExpiring()
+
+	for i, key := range []string{"a", "b", "c", "d"} {
+		_ = e.Set(ctx, key, i)
+		_ = e.ExpireAt(ctx, key, clock.Now().Add(time.Duration(i+1)*time.Minute))
+	}
+
+	// Writes cancel the schedule.
+	_ = e.Set(ctx, "b", 10)
+	_ = e.Delete(ctx, "c")
+
+	clock.Advance(time.Hour)
+
+	if err := e.Sweep(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if v, err := inner.Get(ctx, "b"); err != nil || v != 10 || inner.Len() != 1 {
+		t.Errorf("after Sweep: want only b = 10 left, got %d, %v and %d entries", v, err, inner.Len())
+	}
+}
+
+func TestExpiringSweepRetries(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	e, inner, clock := newExpiring()
+
+	_ = e.Set(ctx, "a", 1)
+	_ = e.ExpireAt(ctx, "a", clock.Now().Add(time.Second))
+
+	inner.FailOn(cachetest.OpDelete, "a", errBoom)
+	clock.Advance(time.Second)
+
+	if err := e.Sweep(ctx); !errors.Is(err, errBoom) {
+		t.Fatalf("Sweep: want errBoom, got %v", err)
+	}
+
+	// The entry stays expired for Get, and is removed by the next Sweep.
+	if _, err := e.Get(ctx, "a"); !errors.Is(err, cache.ErrExpired) {
+		t.Fatalf("Get after failed Sweep: want ErrExpired, got %v", err)
+	}
+
+	inner.FailOn(cachetest.OpDelete, "a", nil)
+	clock.Advance(time.Second)
+
+	if err := e.Sweep(ctx); err != nil || inner.Len() != 0 {
+		t.Errorf("second Sweep: want the entry removed, got %v and %d entries", err, inner.Len())
+	}
+}
+
+func TestExpiringRun(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	inner := cachetest.NewFake[string, int]()
+	e := cache.NewExpiring[string, int](inner, time.Millisecond)
+
+	_ = e.Set(ctx, "a", 1)
+	_ = e.ExpireAt(ctx, "a", time.Now().Add(5*time.Millisecond))
+
+	done := make(chan struct{})
+
+	go func() {
+		e.Run(ctx)
+		close(done)
+	}()
+
+	for deadline := time.Now().Add(5 * time.Second); inner.Len() != 0; {
+		if time.Now().After(deadline) {
+			t.Fatal("Run did not remove the entry")
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel()
+	<-done
+}
@@ -0,0 +1,259 @@
+// Package expiry implements a timing wheel for scheduling key expiration.
+//
+// A Wheel hashes deadlines into a fixed number of buckets, each spanning one
+// tick. Add and Cancel are O(1); Advance only visits the buckets whose ticks
+// have elapsed since the previous call, so expiring keys costs time
+// proportional to the elapsed ticks and the keys stored in them rather than to
+// the total number of keys.
+//
+// Deadlines are rounded up to the next tick: a key never expires before its
+// deadline and at most one tick after it.
+//
+// Times are handled as nanoseconds since the Unix epoch; times outside the
+// representable range (roughly years 1678 to 2262) are clamped to it. The zero
+// time.Time therefore counts as long past.
+//
+// A Wheel is not safe for concurrent use.
+package expiry
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"time"
+)
+
+var (
+	minTime = time.Unix(0, math.MinInt64)
+	maxTime = time.Unix(0, math.MaxInt64)
+)
+
+// Wheel schedules keys of type K for expiration.
+type Wheel[K comparable] struct {
+	buckets []map[K]int64 // key -> deadline tick
+	index   map[K]int     // key -> bucket
+	tick    time.Duration
+	current int64 // last tick passed to Advance
+	started bool  // whether Advance has been called
+}
+
+// New returns a Wheel with the given tick resolution and number of buckets.
+// Deadlines further away than tick*size are supported, at the cost of being
+// visited once per revolution. It panics if tick or size is not positive.
+func New[K comparable](tick time.Duration, size int) *Wheel[K] {
+	if tick <= 0 || size <= 0 {
+		panic("expiry: tick and size must be positive")
+	}
+
+	w := &Wheel[K]{
+		buckets: make([]map[K]int64, size),
+		index:   make(map[K]int),
+		tick:    tick,
+	}
+
+	for i := range w.buckets {
+		w.buckets[i] = make(map[K]int64)
+	}
+
+	return w
+}
+
+// Len returns the number of scheduled keys.
+func (w *Wheel[K]) Len() int {
+	return len(w.index)
+}
+
+// Add schedules key to expire at t, replacing any previous schedule for key.
+// A deadline that has already passed expires on the next Advance.
+func (w *Wheel[K]) Add(key K, t time.Time) {
+	w.Cancel(key)
+
+	deadline := w.ceilTick(t)
+
+	// Deadlines at or before the current tick would otherwise land in a
+	// bucket that has already been visited in this revolution.
+	bucket := w.bucket(deadline)
+	if w.started && deadline <= w.current && w.current < math.MaxInt64 {
+		bucket = w.bucket(w.current + 1)
+	}
+
+	w.buckets[bucket][key] = deadline
+	w.index[key] = bucket
+}
+
+// Cancel removes key from the wheel and reports whether it was scheduled.
+func (w *Wheel[K]) Cancel(key K) bool {
+	bucket, ok := w.index[key]
+	if !ok {
+		return false
+	}
+
+	delete(w.buckets[bucket], key)
+	delete(w.index, key)
+
+	return true
+}
+
+// Deadline returns the deadline of key, rounded up to the tick, and reports
+// whether key is scheduled.
+func (w *Wheel[K]) Deadline(key K) (time.Time, bool) {
+	bucket, ok := w.index[key]
+	if !ok {
+		return time.Time{}, false
+	}
+
+	deadline, d := w.buckets[bucket][key], int64(w.tick)
+
+	// Rounding a deadline near the end of the range up to the tick may not
+	// fit in int64 nanoseconds.
+	if deadline > math.MaxInt64/d {
+		return maxTime, true
+	}
+
+	return time.Unix(0, deadline*d), true
+}
+
+// Advance moves the wheel to now and returns the keys whose deadlines have
+// passed, removing them from the wheel. Moving backwards in time is a no-op.
+func (w *Wheel[K]) Advance(now time.Time) []K {
+	target := w.floorTick(now)
+	if w.started && target <= w.current {
+		return nil
+	}
+
+	var expired []K
+
+	// Visiting more ticks than there are buckets would only revisit them. The
+	// ticks are counted as a difference so that nothing overflows near the
+	// ends of the clamped range.
+	size := uint64(len(w.buckets))
+	if elapsed := uint64(target) - uint64(w.current); !w.started || elapsed >= size {
+		for bucket := range w.buckets {
+			expired = w.expire(bucket, target, expired)
+		}
+	} else {
+		for i := range int64(elapsed) {
+			expired = w.expire(w.bucket(w.current+1+i), target, expired)
+		}
+	}
+
+	w.current = target
+	w.started = true
+
+	return expired
+}
+
+// ExpiringBefore returns the keys that Advance(t) would expire, ordered by
+// deadline, without removing them, e.g. to refresh them ahead of time. Keys
+// whose deadlines round up to the same tick are in no particular order. Like
+// Advance, it only visits the buckets of the ticks up to t.
+func (w *Wheel[K]) ExpiringBefore(t time.Time) []K {
+	target := w.floorTick(t)
+
+	type entry struct {
+		key      K
+		deadline int64
+	}
+
+	var found []entry
+
+	collect := func(bucket int) {
+		for key, deadline := range w.buckets[bucket] {
+			if deadline <= target {
+				found = append(found, entry{key, deadline})
+			}
+		}
+	}
+
+	// Keys added with a deadline that had already passed wait in the bucket
+	// of the next tick, so it is visited even if t is not past it.
+	ticks := uint64(1)
+	if w.started && target > w.current {
+		ticks = uint64(target) - uint64(w.current)
+	}
+
+	if !w.started || ticks >= uint64(len(w.buckets)) || w.current == math.MaxInt64 {
+		for bucket := range w.buckets {
+			collect(bucket)
+		}
+	} else {
+		for i := range int64(ticks) {
+			collect(w.bucket(w.current + 1 + i))
+		}
+	}
+
+	slices.SortFunc(found, func(a, b entry) int {
+		return cmp.Compare(a.deadline, b.deadline)
+	})
+
+	keys := make([]K, len(found))
+	for i, e := range found {
+		keys[i] = e.key
+	}
+
+	return keys
+}
+
+// expire removes the keys of bucket whose deadlines are at or before target and
+// appends them to expired.
+func (w *Wheel[K]) expire(bucket int, target int64, expired []K) []K {
+	for key, deadline := range w.buckets[bucket] {
+		if deadline <= target {
+			delete(w.buckets[bucket], key)
+			delete(w.index, key)
+
+			expired = append(expired, key)
+		}
+	}
+
+	return expired
+}
+
+// Clear removes all keys from the wheel.
+func (w *Wheel[K]) Clear() {
+	for _, b := range w.buckets {
+		clear(b)
+	}
+
+	clear(w.index)
+}
+
+func (w *Wheel[K]) bucket(tick int64) int {
+	n := int64(len(w.buckets))
+
+	return int((tick%n + n) % n)
+}
+
+func (w *Wheel[K]) floorTick(t time.Time) int64 {
+	n, d := unixNano(t), int64(w.tick)
+
+	q := n / d
+	if n%d < 0 {
+		q--
+	}
+
+	return q
+}
+
+func (w *Wheel[K]) ceilTick(t time.Time) int64 {
+	n, d := unixNano(t), int64(w.tick)
+
+	q := n / d
+	if n%d > 0 {
+		q++
+	}
+
+	return q
+}
+
+// unixNano returns t.UnixNano, clamped to the range it can represent.
+func unixNano(t time.Time) int64 {
+	switch {
+	case t.Before(minTime):
+		return math.MinInt64
+	case t.After(maxTime):
+		return math.MaxInt64
+	default:
+		return t.UnixNano()
+	}
+}
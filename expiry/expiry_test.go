@@ -0,0 +1,293 @@
+package expiry_test
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"go.expect.digital/cache/expiry"
+)
+
+var epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func at(d time.Duration) time.Time {
+	return epoch.Add(d)
+}
+
+func checkAdvance(t *testing.T, w *expiry.Wheel[string], now time.Time, want ...string) {
+	t.Helper()
+
+	got := w.Advance(now)
+	slices.Sort(got)
+
+	if !slices.Equal(got, want) {
+		t.Fatalf("Advance(%v): want %v, got %v", now.Sub(epoch), want, got)
+	}
+}
+
+func TestNewPanics(t *testing.T) {
+	t.Parallel()
+
+	for _, args := range []struct {
+		tick time.Duration
+		size int
+	}{{0, 8}, {-time.Second, 8}, {time.Second, 0}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("New(%v, %d) did not panic", args.tick, args.size)
+				}
+			}()
+
+			expiry.New[string](args.tick, args.size)
+		}()
+	}
+}
+
+func TestDeadlineRoundsUp(t *testing.T) {
+	t.Parallel()
+
+	w := expiry.New[string](time.Second, 8)
+	w.Advance(epoch)
+
+	w.Add("fraction", at(1500*time.Millisecond))
+	w.Add("exact", at(2*time.Second))
+
+	for key, want := range map[string]time.Time{"fraction": at(2 * time.Second), "exact": at(2 * time.Second)} {
+		if got, ok := w.Deadline(key); !ok || !got.Equal(want) {
+			t.Errorf("Deadline(%s): want %v, got %v (%t)", key, want, got, ok)
+		}
+	}
+
+	// Never before the deadline, even within the same tick.
+	checkAdvance(t, w, at(1500*time.Millisecond))
+	checkAdvance(t, w, at(1999*time.Millisecond))
+	checkAdvance(t, w, at(2*time.Second), "exact", "fraction")
+}
+
+func TestAddPastDeadline(t *testing.T) {
+	t.Parallel()
+
+	w := expiry.New[string](time.Second, 8)
+	w.Advance(at(10 * time.Second))
+
+	// The bucket of tick 9 was already visited in this revolution.
+	w.Add("past", at(9*time.Second))
+	w.Add("now", at(10*time.Second))
+
+	checkAdvance(t, w, at(10500*time.Millisecond))
+	checkAdvance(t, w, at(11*time.Second), "now", "past")
+
+	if w.Len() != 0 {
+		t.Errorf("Len: want 0, got %d", w.Len())
+	}
+}
+
+func TestAddBeforeFirstAdvance(t *testing.T) {
+	t.Parallel()
+
+	w := expiry.New[string](time.Second, 8)
+	w.Add("past", at(-time.Hour))
+	w.Add("future", at(time.Second))
+
+	checkAdvance(t, w, epoch, "past")
+	checkAdvance(t, w, at(time.Second), "future")
+}
+
+func TestDeadlineBeyondWheel(t *testing.T) {
+	t.Parallel()
+
+	const size = 8
+
+	w := expiry.New[string](time.Second, size)
+	w.Advance(epoch)
+
+	// Due after three and a half revolutions.
+	w.Add("far", at((3*size+4)*time.Second))
+
+	for tick := 1; tick < 3*size+4; tick++ {
+		checkAdvance(t, w, at(time.Duration(tick)*time.Second))
+	}
+
+	checkAdvance(t, w, at((3*size+4)*time.Second), "far")
+}
+
+func TestAdvanceJumpBeyondWheel(t *testing.T) {
+	t.Parallel()
+
+	w := expiry.New[string](time.Second, 8)
+	w.Advance(epoch)
+
+	for i, key := range []string{"a", "b", "c", "d", "e", "f", "g", "h", "i"} {
+		w.Add(key, at(time.Duration(i+1)*time.Second))
+	}
+
+	w.Add("later", at(100*time.Second))
+
+	checkAdvance(t, w, at(50*time.Second), "a", "b", "c", "d", "e", "f", "g", "h", "i")
+	checkAdvance(t, w, at(100*time.Second), "later")
+}
+
+func TestAdvanceBackwards(t *testing.T) {
+	t.Parallel()
+
+	w := expiry.New[string](time.Second, 8)
+	w.Advance(at(10 * time.Second))
+	w.Add("a", at(11*time.Second))
+
+	checkAdvance(t, w, at(5*time.Second))
+	checkAdvance(t, w, at(10*time.Second))
+
+	// The backwards call did not rewind the wheel: a past deadline added now
+	// still lands after tick 10.
+	w.Add("b", at(7*time.Second))
+	checkAdvance(t, w, at(11*time.Second), "a", "b")
+}
+
+func TestCancel(t *testing.T) {
+	t.Parallel()
+
+	w := expiry.New[string](time.Second, 8)
+	w.Advance(epoch)
+	w.Add("a", at(time.Second))
+
+	if !w.Cancel("a") {
+		t.Fatal("Cancel of scheduled key reported false")
+	}
+
+	if w.Cancel("a") {
+		t.Fatal("Cancel of unscheduled key reported true")
+	}
+
+	if _, ok := w.Deadline("a"); ok {
+		t.Fatal("Deadline of cancelled key reported true")
+	}
+
+	checkAdvance(t, w, at(time.Second))
+}
+
+func TestAddReplaces(t *testing.T) {
+	t.Parallel()
+
+	w := expiry.New[string](time.Second, 8)
+	w.Advance(epoch)
+
+	w.Add("later", at(time.Second))
+	w.Add("later", at(3*time.Second))
+
+	w.Add("sooner", at(5*time.Second))
+	w.Add("sooner", at(2*time.Second))
+
+	if w.Len() != 2 {
+		t.Fatalf("Len: want 2, got %d", w.Len())
+	}
+
+	checkAdvance(t, w, at(time.Second))
+	checkAdvance(t, w, at(2*time.Second), "sooner")
+	checkAdvance(t, w, at(3*time.Second), "later")
+	checkAdvance(t, w, at(5*time.Second))
+}
+
+func TestClear(t *testing.T) {
+	t.Parallel()
+
+	w := expiry.New[string](time.Second, 8)
+	w.Add("a", at(time.Second))
+	w.Clear()
+
+	if w.Len() != 0 {
+		t.Fatalf("Len: want 0, got %d", w.Len())
+	}
+
+	checkAdvance(t, w, at(time.Second))
+}
+
+func TestOutOfRangeTimes(t *testing.T) {
+	t.Parallel()
+
+	w := expiry.New[string](time.Second, 8)
+
+	w.Add("zero", time.Time{})
+	w.Add("distant", time.Date(3000, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	if got, _ := w.Deadline("zero"); got.Year() != 1677 {
+		t.Errorf("Deadline(zero): want clamped to the earliest time, got %v", got)
+	}
+
+	if got, _ := w.Deadline("distant"); got.Year() != 2262 {
+		t.Errorf("Deadline(distant): want clamped to the latest time, got %v", got)
+	}
+
+	checkAdvance(t, w, epoch, "zero")
+
+	if w.Len() != 1 {
+		t.Errorf("Len: want 1, got %d", w.Len())
+	}
+}
+
+func TestAdvanceRangeEnds(t *testing.T) {
+	t.Parallel()
+
+	distant := time.Date(3000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// With a 1ns tick the clamped ticks are the ends of the int64 range.
+	w := expiry.New[string](time.Nanosecond, 8)
+	w.Add("zero", time.Time{})
+
+	checkAdvance(t, w, time.Time{}, "zero")
+
+	w.Add("epoch", epoch)
+	w.Add("distant", distant)
+
+	checkAdvance(t, w, epoch, "epoch")
+	checkAdvance(t, w, distant, "distant")
+	checkAdvance(t, w, distant)
+}
+
+func TestExpiringBefore(t *testing.T) {
+	t.Parallel()
+
+	w := expiry.New[string](time.Second, 8)
+
+	// Before the first Advance, every bucket is searched.
+	w.Add("b", at(2*time.Second))
+	w.Add("a", at(time.Second))
+
+	if got := w.ExpiringBefore(at(5 * time.Second)); !slices.Equal(got, []string{"a", "b"}) {
+		t.Fatalf("ExpiringBefore before Advance: want [a b], got %v", got)
+	}
+
+	w.Advance(epoch)
+
+	w.Add("far", at(20*time.Second)) // shares a bucket with d
+	w.Add("d", at(4*time.Second))
+	w.Add("c", at(2500*time.Millisecond))
+
+	for _, tt := range []struct {
+		t    time.Time
+		want []string
+	}{
+		{epoch, nil},
+		{at(2 * time.Second), []string{"a", "b"}},
+		{at(4 * time.Second), []string{"a", "b", "c", "d"}},
+		{at(time.Minute), []string{"a", "b", "c", "d", "far"}},
+	} {
+		if got := w.ExpiringBefore(tt.t); !slices.Equal(got, tt.want) {
+			t.Errorf("ExpiringBefore(%v): want %v, got %v", tt.t.Sub(epoch), tt.want, got)
+		}
+	}
+
+	if w.Len() != 5 {
+		t.Fatalf("Len: want 5 keys left in place, got %d", w.Len())
+	}
+
+	// A key whose deadline has passed shows up until Advance expires it.
+	w.Advance(at(3 * time.Second))
+	w.Add("late", epoch)
+
+	if got := w.ExpiringBefore(at(3 * time.Second)); !slices.Equal(got, []string{"late"}) {
+		t.Errorf("ExpiringBefore(now): want [late], got %v", got)
+	}
+
+	checkAdvance(t, w, at(4*time.Second), "d", "late")
+}
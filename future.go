@@ -0,0 +1,62 @@
+package cache
+
+import (
+	"context"
+	"runtime/debug"
+)
+
+// Future is the pending result of a Loading.GetAsync.
+type Future[V any] struct {
+	done  chan struct{} // closed when value and err are set
+	value V
+	err   error
+}
+
+// GetAsync starts a Get of key in a new goroutine and returns its pending
+// result, so that callers can start several lookups and join them later.
+// Loads are shared with concurrent Gets of key as usual.
+//
+// The Get runs with ctx. If the getter panics, the result is a *PanicError
+// rather than a crash of the program, since no caller could recover it.
+func (l *Loading[K, V]) GetAsync(ctx context.Context, key K) *Future[V] {
+	f := &Future[V]{done: make(chan struct{})}
+
+	go func() {
+		returned := false
+
+		defer func() {
+			if !returned {
+				if r := recover(); r != nil {
+					f.err = &PanicError{Value: r, Stack: debug.Stack()}
+				} else {
+					f.err = errGoexit
+				}
+			}
+
+			close(f.done)
+		}()
+
+		f.value, f.err = l.Get(ctx, key)
+		returned = true
+	}()
+
+	return f
+}
+
+// Done returns a channel that is closed when the result is ready.
+func (f *Future[V]) Done() <-chan struct{} {
+	return f.done
+}
+
+// Wait waits for the result and returns it. If ctx is done first, Wait
+// returns ctx.Err(); the Get carries on, and Wait can be called again.
+func (f *Future[V]) Wait(ctx context.Context) (V, error) {
+	select {
+	case <-f.done:
+		return f.value, f.err
+	case <-ctx.Done():
+		var zero V
+
+		return zero, ctx.Err()
+	}
+}
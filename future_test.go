@@ -0,0 +1,98 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"testing"
+	"time"
+
+	"go.expect.digital/cache"
+	"go.expect.digital/cache/cachetest"
+)
+
+func TestGetAsync(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	release := make(chan struct{})
+
+	l := cache.NewLoading(cachetest.NewFake[string, string](), func(_ context.Context, key string) (string, error) {
+		<-release
+
+		if key == "missing" {
+			return "", cache.ErrNotFound
+		}
+
+		return "value of " + key, nil
+	})
+
+	a, b, missing := l.GetAsync(ctx, "a"), l.GetAsync(ctx, "b"), l.GetAsync(ctx, "missing")
+
+	select {
+	case <-a.Done():
+		t.Fatal("future done before its load")
+	default:
+	}
+
+	close(release)
+
+	for key, f := range map[string]*cache.Future[string]{"a": a, "b": b} {
+		if v, err := f.Wait(ctx); err != nil || v != "value of "+key {
+			t.Errorf("Wait(%s): want %q, got %q, %v", key, "value of "+key, v, err)
+		}
+	}
+
+	if _, err := missing.Wait(ctx); !errors.Is(err, cache.ErrNotFound) {
+		t.Errorf("Wait(missing): want ErrNotFound, got %v", err)
+	}
+}
+
+func TestFutureWaitContext(t *testing.T) {
+	t.Parallel()
+
+	release := make(chan struct{})
+
+	l := cache.NewLoading(cachetest.NewFake[string, int](), func(context.Context, string) (int, error) {
+		<-release
+
+		return 1, nil
+	})
+
+	f := l.GetAsync(context.Background(), "a")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if _, err := f.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Wait: want DeadlineExceeded, got %v", err)
+	}
+
+	// The Get carried on regardless.
+	close(release)
+
+	if v, err := f.Wait(context.Background()); err != nil || v != 1 {
+		t.Errorf("Wait again: want 1, got %d, %v", v, err)
+	}
+}
+
+func TestGetAsyncPanic(t *testing.T) {
+	t.Parallel()
+
+	l := cache.NewLoading(cachetest.NewFake[string, int](), func(_ context.Context, key string) (int, error) {
+		if key == "exit" {
+			runtime.Goexit()
+		}
+
+		panic(errBoom)
+	})
+
+	var pe *cache.PanicError
+	if _, err := l.GetAsync(context.Background(), "a").Wait(context.Background()); !errors.As(err, &pe) || pe.Value != errBoom {
+		t.Errorf("Wait: want *PanicError of errBoom, got %v", err)
+	}
+
+	if _, err := l.GetAsync(context.Background(), "exit").Wait(context.Background()); err == nil {
+		t.Error("Wait after runtime.Goexit: want an error, got nil")
+	}
+}
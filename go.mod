@@ -0,0 +1,3 @@
+module go.expect.digital/cache
+
+go 1.23
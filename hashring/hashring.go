@@ -0,0 +1,239 @@
+// Package hashring implements a consistent hash ring with virtual nodes and
+// weighted members.
+//
+// Each member is placed on the ring at replicas*weight points derived only
+// from its name, so adding or removing a member moves just the keys that
+// belong to it; every other key keeps its owner.
+//
+// A Ring is safe for concurrent use.
+package hashring
+
+import (
+	"cmp"
+	"hash/fnv"
+	"slices"
+	"strconv"
+	"sync"
+)
+
+const (
+	// DefaultReplicas is the number of virtual nodes placed per unit of
+	// weight when WithReplicas is not given.
+	DefaultReplicas = 160
+
+	// MaxReplicas is the largest number of virtual nodes per unit of weight.
+	MaxReplicas = 1 << 12
+
+	// MaxWeight is the largest member weight. Together with MaxReplicas it
+	// bounds the points of a single member, so that they cannot overflow.
+	MaxWeight = 1 << 10
+)
+
+// HashFunc maps a key to a position on the ring.
+type HashFunc func(data []byte) uint64
+
+// Option configures a Ring.
+type Option func(*Ring)
+
+// WithReplicas sets the number of virtual nodes per unit of member weight.
+// Values below 1 are ignored and values above MaxReplicas are capped.
+func WithReplicas(n int) Option {
+	return func(r *Ring) {
+		if n > 0 {
+			r.replicas = min(n, MaxReplicas)
+		}
+	}
+}
+
+// WithHash sets the function used to place members and keys on the ring.
+// It must be deterministic across processes if rings are to agree.
+func WithHash(fn HashFunc) Option {
+	return func(r *Ring) {
+		if fn != nil {
+			r.hash = fn
+		}
+	}
+}
+
+type point struct {
+	member string
+	hash   uint64
+}
+
+// Ring is a consistent hash ring.
+type Ring struct {
+	hash     HashFunc
+	members  map[string]int
+	points   []point
+	replicas int
+	mu       sync.RWMutex
+}
+
+// New returns an empty Ring.
+func New(opts ...Option) *Ring {
+	r := &Ring{
+		hash:     defaultHash,
+		members:  make(map[string]int),
+		replicas: DefaultReplicas,
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+// Add adds member with the given weight, or changes the weight of an existing
+// member. A weight below 1 is treated as 1 and a weight above MaxWeight as
+// MaxWeight.
+func (r *Ring) Add(member string, weight int) {
+	weight = min(max(weight, 1), MaxWeight)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.members[member] == weight {
+		return
+	}
+
+	r.members[member] = weight
+	r.rebuild()
+}
+
+// Remove removes member from the ring. Removing an unknown member is a no-op.
+func (r *Ring) Remove(member string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.members[member]; !ok {
+		return
+	}
+
+	delete(r.members, member)
+	r.rebuild()
+}
+
+// Get returns the member owning key. It reports false if the ring is empty.
+func (r *Ring) Get(key string) (string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(r.points) == 0 {
+		return "", false
+	}
+
+	return r.points[r.search(key)].member, true
+}
+
+// GetN returns up to n distinct members for key in ring order, starting with
+// its owner. It is intended for choosing replicas.
+func (r *Ring) GetN(key string, n int) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	n = min(n, len(r.members))
+	if n <= 0 {
+		return nil
+	}
+
+	result := make([]string, 0, n)
+
+	for i, start := 0, r.search(key); len(result) < n && i < len(r.points); i++ {
+		member := r.points[(start+i)%len(r.points)].member
+		if !slices.Contains(result, member) {
+			result = append(result, member)
+		}
+	}
+
+	return result
+}
+
+// Members returns the current members in sorted order.
+func (r *Ring) Members() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	members := make([]string, 0, len(r.members))
+	for member := range r.members {
+		members = append(members, member)
+	}
+
+	slices.Sort(members)
+
+	return members
+}
+
+// Weight returns the weight of member, or 0 if it is not on the ring.
+func (r *Ring) Weight(member string) int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.members[member]
+}
+
+// Len returns the number of members.
+func (r *Ring) Len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return len(r.members)
+}
+
+// search returns the index of the first point at or after the hash of key,
+// wrapping around to 0. The ring must not be empty.
+func (r *Ring) search(key string) int {
+	h := r.hash([]byte(key))
+
+	i, _ := slices.BinarySearchFunc(r.points, h, func(p point, h uint64) int {
+		return cmp.Compare(p.hash, h)
+	})
+
+	if i == len(r.points) {
+		return 0
+	}
+
+	return i
+}
+
+// rebuild recomputes the sorted points. Callers must hold the write lock.
+func (r *Ring) rebuild() {
+	n := 0
+	for _, weight := range r.members {
+		n += weight * r.replicas
+	}
+
+	points := make([]point, 0, n)
+	buf := make([]byte, 0, 64)
+
+	for member, weight := range r.members {
+		for i := range weight * r.replicas {
+			buf = strconv.AppendInt(append(append(buf[:0], member...), '#'), int64(i), 10)
+			points = append(points, point{member: member, hash: r.hash(buf)})
+		}
+	}
+
+	// Ties are broken by member name so that every process builds the same
+	// ring regardless of map iteration order.
+	slices.SortFunc(points, func(a, b point) int {
+		return cmp.Or(cmp.Compare(a.hash, b.hash), cmp.Compare(a.member, b.member))
+	})
+
+	r.points = points
+}
+
+// defaultHash is FNV-1a followed by a 64-bit finalizer, which spreads the
+// otherwise clustered hashes of similar strings such as "node#1", "node#2".
+func defaultHash(data []byte) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write(data)
+
+	x := h.Sum64()
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+
+	return x
+}
@@ -0,0 +1,254 @@
+package hashring_test
+
+import (
+	"math"
+	"slices"
+	"strconv"
+	"testing"
+
+	"go.expect.digital/cache/hashring"
+)
+
+const numKeys = 20000
+
+func owners(t *testing.T, r *hashring.Ring) []string {
+	t.Helper()
+
+	result := make([]string, numKeys)
+
+	for i := range result {
+		member, ok := r.Get("key" + strconv.Itoa(i))
+		if !ok {
+			t.Fatal("Get on non-empty ring reported false")
+		}
+
+		result[i] = member
+	}
+
+	return result
+}
+
+func newRing(members ...string) *hashring.Ring {
+	r := hashring.New()
+	for _, m := range members {
+		r.Add(m, 1)
+	}
+
+	return r
+}
+
+func TestEmpty(t *testing.T) {
+	t.Parallel()
+
+	r := hashring.New()
+
+	if _, ok := r.Get("key"); ok {
+		t.Error("Get on empty ring reported true")
+	}
+
+	if got := r.GetN("key", 3); got != nil {
+		t.Errorf("GetN on empty ring: want nil, got %v", got)
+	}
+}
+
+func TestAddMovesOnlyToNewMember(t *testing.T) {
+	t.Parallel()
+
+	r := newRing("a", "b", "c")
+	before := owners(t, r)
+
+	r.Add("d", 1)
+	after := owners(t, r)
+
+	moved := 0
+
+	for i := range before {
+		if before[i] == after[i] {
+			continue
+		}
+
+		moved++
+
+		if after[i] != "d" {
+			t.Fatalf("key%d moved from %s to %s, want d", i, before[i], after[i])
+		}
+	}
+
+	// d should take roughly a quarter of the keys.
+	if want := numKeys / 4; math.Abs(float64(moved-want)) > 0.2*float64(want) {
+		t.Errorf("moved %d keys, want about %d", moved, want)
+	}
+}
+
+func TestRemoveMovesOnlyRemovedMember(t *testing.T) {
+	t.Parallel()
+
+	r := newRing("a", "b", "c", "d")
+	before := owners(t, r)
+
+	r.Remove("b")
+	after := owners(t, r)
+
+	for i := range before {
+		if before[i] != "b" && before[i] != after[i] {
+			t.Fatalf("key%d moved from %s to %s, but only b's keys may move", i, before[i], after[i])
+		}
+
+		if after[i] == "b" {
+			t.Fatalf("key%d still owned by removed member", i)
+		}
+	}
+
+	r.Add("b", 1)
+
+	if got := owners(t, r); !slices.Equal(got, before) {
+		t.Error("re-adding b did not restore the original ownership")
+	}
+}
+
+func TestRaiseWeightMovesOnlyToMember(t *testing.T) {
+	t.Parallel()
+
+	r := newRing("a", "b", "c")
+	before := owners(t, r)
+
+	r.Add("b", 3)
+	after := owners(t, r)
+
+	moved := 0
+
+	for i := range before {
+		if before[i] == after[i] {
+			continue
+		}
+
+		moved++
+
+		if after[i] != "b" {
+			t.Fatalf("key%d moved from %s to %s, want b", i, before[i], after[i])
+		}
+	}
+
+	// b goes from a third of the keys to three fifths.
+	if want := numKeys * 4 / 15; math.Abs(float64(moved-want)) > 0.2*float64(want) {
+		t.Errorf("moved %d keys, want about %d", moved, want)
+	}
+}
+
+func TestWeightLimit(t *testing.T) {
+	t.Parallel()
+
+	// Neither limit may overflow the number of points.
+	for _, r := range []*hashring.Ring{
+		hashring.New(hashring.WithReplicas(1)),
+		hashring.New(hashring.WithReplicas(math.MaxInt)),
+	} {
+		r.Add("a", math.MaxInt/2)
+		r.Add("b", 1)
+
+		if got := r.Weight("a"); got != hashring.MaxWeight {
+			t.Fatalf("Weight: want %d, got %d", hashring.MaxWeight, got)
+		}
+
+		if _, ok := r.Get("key"); !ok {
+			t.Error("Get on non-empty ring reported false")
+		}
+	}
+}
+
+func TestWeights(t *testing.T) {
+	t.Parallel()
+
+	r := hashring.New()
+	r.Add("a", 1)
+	r.Add("b", 2)
+	r.Add("c", 3)
+
+	counts := make(map[string]int)
+	for _, m := range owners(t, r) {
+		counts[m]++
+	}
+
+	for member, weight := range map[string]int{"a": 1, "b": 2, "c": 3} {
+		want := float64(numKeys*weight) / 6
+		if got := float64(counts[member]); math.Abs(got-want) > 0.2*want {
+			t.Errorf("%s owns %v keys, want about %v", member, got, want)
+		}
+	}
+}
+
+func TestGetN(t *testing.T) {
+	t.Parallel()
+
+	r := newRing("a", "b", "c", "d")
+
+	for i := range 100 {
+		key := "key" + strconv.Itoa(i)
+		owner, _ := r.Get(key)
+
+		got := r.GetN(key, 3)
+		if len(got) != 3 {
+			t.Fatalf("GetN(%s, 3): want 3 members, got %v", key, got)
+		}
+
+		if got[0] != owner {
+			t.Errorf("GetN(%s, 3): want owner %s first, got %v", key, owner, got)
+		}
+
+		if sorted := slices.Compact(slices.Sorted(slices.Values(got))); len(sorted) != len(got) {
+			t.Errorf("GetN(%s, 3): duplicate members in %v", key, got)
+		}
+	}
+
+	if got := r.GetN("key", 10); len(got) != 4 {
+		t.Errorf("GetN beyond member count: want 4 members, got %v", got)
+	}
+}
+
+func TestInsertionOrderIndependent(t *testing.T) {
+	t.Parallel()
+
+	// A constant hash makes every point collide, so ownership depends only
+	// on the tie-break between members.
+	constant := hashring.WithHash(func([]byte) uint64 { return 7 })
+
+	for _, opts := range [][]hashring.Option{nil, {constant}} {
+		r1 := hashring.New(opts...)
+		r2 := hashring.New(opts...)
+
+		for _, m := range []string{"a", "b", "c", "d"} {
+			r1.Add(m, 1)
+		}
+
+		for _, m := range []string{"d", "c", "b", "a"} {
+			r2.Add(m, 1)
+		}
+
+		if !slices.Equal(owners(t, r1), owners(t, r2)) {
+			t.Error("rings built in different orders disagree")
+		}
+	}
+}
+
+func TestMembers(t *testing.T) {
+	t.Parallel()
+
+	r := hashring.New()
+	r.Add("b", 2)
+	r.Add("a", 0)
+
+	if got := r.Members(); !slices.Equal(got, []string{"a", "b"}) {
+		t.Errorf("Members: want [a b], got %v", got)
+	}
+
+	if r.Weight("a") != 1 || r.Weight("b") != 2 || r.Weight("z") != 0 {
+		t.Errorf("Weight: want 1, 2, 0, got %d, %d, %d", r.Weight("a"), r.Weight("b"), r.Weight("z"))
+	}
+
+	r.Remove("a")
+	r.Remove("z")
+
+	if r.Len() != 1 {
+		t.Errorf("Len: want 1, got %d", r.Len())
+	}
+}
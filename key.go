@@ -0,0 +1,16 @@
+package cache
+
+// Key2 is a key made of two parts, for caches keyed by more than one value.
+// Unlike a key built with fmt.Sprintf, it does not allocate, and its parts
+// cannot run into each other: Key2{"a:b", "c"} and Key2{"a", "b:c"} differ.
+type Key2[A, B comparable] struct {
+	A A
+	B B
+}
+
+// Key3 is a key made of three parts, like Key2.
+type Key3[A, B, C comparable] struct {
+	A A
+	B B
+	C C
+}
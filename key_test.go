@@ -0,0 +1,47 @@
+package cache_test
+
+import (
+	"context"
+	"testing"
+
+	"go.expect.digital/cache"
+	"go.expect.digital/cache/cachetest"
+)
+
+func TestKey2(t *testing.T) {
+	t.Parallel()
+
+	type key = cache.Key2[string, string]
+
+	ctx := context.Background()
+	c := cachetest.NewFake[key, int]()
+
+	_ = c.Set(ctx, key{"a:b", "c"}, 1)
+	_ = c.Set(ctx, key{"a", "b:c"}, 2)
+
+	for k, want := range map[key]int{{"a:b", "c"}: 1, {"a", "b:c"}: 2} {
+		if v, err := c.Get(ctx, k); err != nil || v != want {
+			t.Errorf("Get(%v): want %d, got %d, %v", k, want, v, err)
+		}
+	}
+}
+
+func TestKey3(t *testing.T) {
+	t.Parallel()
+
+	type key = cache.Key3[string, int, bool]
+
+	ctx := context.Background()
+	c := cachetest.NewFake[key, string]()
+
+	_ = c.Set(ctx, key{"user", 1, true}, "a")
+	_ = c.Set(ctx, key{"user", 1, false}, "b")
+
+	if v, err := c.Get(ctx, key{"user", 1, true}); err != nil || v != "a" {
+		t.Errorf("Get: want %q, got %q, %v", "a", v, err)
+	}
+
+	if c.Len() != 2 {
+		t.Errorf("Len: want 2 distinct keys, got %d", c.Len())
+	}
+}
@@ -0,0 +1,41 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// WithKeyFunc returns a view of c that passes every key through fn before
+// using it, so that equivalent keys, such as host names differing only in
+// case, share one entry. fn must be deterministic.
+//
+// The view implements TTLSetter, storing entries without expiration if c
+// does not implement it.
+func WithKeyFunc[K comparable, V any](c Interface[K, V], fn func(key K) K) Interface[K, V] {
+	return keyFunc[K, V]{c, fn}
+}
+
+type keyFunc[K comparable, V any] struct {
+	c  Interface[K, V]
+	fn func(K) K
+}
+
+func (k keyFunc[K, V]) Get(ctx context.Context, key K) (V, error) {
+	return k.c.Get(ctx, k.fn(key))
+}
+
+func (k keyFunc[K, V]) Set(ctx context.Context, key K, value V) error {
+	return k.c.Set(ctx, k.fn(key), value)
+}
+
+func (k keyFunc[K, V]) SetWithTTL(ctx context.Context, key K, value V, ttl time.Duration) error {
+	return setWithTTL(ctx, k.c, k.fn(key), value, ttl)
+}
+
+func (k keyFunc[K, V]) Delete(ctx context.Context, key K) error {
+	return k.c.Delete(ctx, k.fn(key))
+}
+
+func (k keyFunc[K, V]) Len() int {
+	return k.c.Len()
+}
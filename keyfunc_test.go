@@ -0,0 +1,56 @@
+package cache_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"go.expect.digital/cache"
+	"go.expect.digital/cache/cachetest"
+)
+
+func TestWithKeyFuncConformance(t *testing.T) {
+	t.Parallel()
+
+	cachetest.Run(t, func(*testing.T) cache.Interface[string, string] {
+		return cache.WithKeyFunc[string, string](cachetest.NewFake[string, string](), strings.ToLower)
+	})
+}
+
+func TestWithKeyFunc(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	inner := cachetest.NewFake[string, int]()
+	c := cache.WithKeyFunc[string, int](inner, func(key string) string {
+		return strings.ToLower(strings.TrimSpace(key))
+	})
+
+	_ = c.Set(ctx, " Example.COM", 1)
+
+	for _, key := range []string{"example.com", "EXAMPLE.COM ", " Example.com"} {
+		if v, err := c.Get(ctx, key); err != nil || v != 1 {
+			t.Errorf("Get(%q): want 1, got %d, %v", key, v, err)
+		}
+	}
+
+	if v, err := inner.Get(ctx, "example.com"); err != nil || v != 1 || inner.Len() != 1 {
+		t.Fatalf("inner: want one entry under the canonical key, got %d, %v and %d entries", v, err, inner.Len())
+	}
+
+	ttl, ok := c.(cache.TTLSetter[string, int])
+	if !ok {
+		t.Fatal("view does not implement TTLSetter")
+	}
+
+	_ = ttl.SetWithTTL(ctx, "EXAMPLE.com", 2, time.Minute)
+	cachetest.CheckExpiresAfter(t, c, inner.Clock(), "Example.Com", 2, time.Minute)
+
+	_ = c.Set(ctx, "example.com", 3)
+	_ = c.Delete(ctx, "Example.COM")
+
+	if inner.Len() != 0 {
+		t.Errorf("Len after Delete: want 0, got %d", inner.Len())
+	}
+}
@@ -0,0 +1,337 @@
+// Package list implements a generic doubly linked list.
+//
+// The API mirrors container/list, with the element value typed as V instead
+// of any. The zero value of List is an empty list ready to use.
+//
+// One difference matters when porting code: PushBackList and PushFrontList
+// move the elements of the other list instead of copying them, leaving the
+// other list empty. To keep the source list intact, push its values one by
+// one instead.
+//
+// To iterate over a list (where l is a *List[V]):
+//
+//	for e := l.Front(); e != nil; e = e.Next() {
+//		// do something with e.Value
+//	}
+//
+// or, when elements are not removed during iteration:
+//
+//	for v := range l.All() {
+//		// do something with v
+//	}
+package list
+
+import "iter"
+
+// Element is an element of a linked list.
+type Element[V any] struct {
+	// next and prev point to the neighbouring elements. Internally the list
+	// is a ring with &l.root as both the sentinel before Front and after Back.
+	next, prev *Element[V]
+
+	// list is the list this element belongs to, nil if it is not in a list.
+	list *List[V]
+
+	// Value is the value stored with this element.
+	Value V
+}
+
+// Next returns the next list element or nil.
+func (e *Element[V]) Next() *Element[V] {
+	if p := e.next; e.list != nil && p != &e.list.root {
+		return p
+	}
+
+	return nil
+}
+
+// Prev returns the previous list element or nil.
+func (e *Element[V]) Prev() *Element[V] {
+	if p := e.prev; e.list != nil && p != &e.list.root {
+		return p
+	}
+
+	return nil
+}
+
+// List is a doubly linked list. The zero value is an empty list ready to use.
+type List[V any] struct {
+	root Element[V]
+	len  int
+}
+
+// New returns an initialized list.
+func New[V any]() *List[V] {
+	return new(List[V]).Init()
+}
+
+// Init initializes or clears list l.
+func (l *List[V]) Init() *List[V] {
+	l.root.next = &l.root
+	l.root.prev = &l.root
+	l.len = 0
+
+	return l
+}
+
+// Len returns the number of elements of list l. The complexity is O(1).
+func (l *List[V]) Len() int {
+	return l.len
+}
+
+// Front returns the first element of list l or nil if the list is empty.
+func (l *List[V]) Front() *Element[V] {
+	if l.len == 0 {
+		return nil
+	}
+
+	return l.root.next
+}
+
+// Back returns the last element of list l or nil if the list is empty.
+func (l *List[V]) Back() *Element[V] {
+	if l.len == 0 {
+		return nil
+	}
+
+	return l.root.prev
+}
+
+// All returns an iterator over the values of l from front to back.
+// The list must not be modified during iteration.
+func (l *List[V]) All() iter.Seq[V] {
+	return func(yield func(V) bool) {
+		for e := l.Front(); e != nil; e = e.Next() {
+			if !yield(e.Value) {
+				return
+			}
+		}
+	}
+}
+
+// Backward returns an iterator over the values of l from back to front.
+// The list must not be modified during iteration.
+func (l *List[V]) Backward() iter.Seq[V] {
+	return func(yield func(V) bool) {
+		for e := l.Back(); e != nil; e = e.Prev() {
+			if !yield(e.Value) {
+				return
+			}
+		}
+	}
+}
+
+// Remove removes e from l if e is an element of list l and returns the
+// element value e.Value. The element must not be nil.
+func (l *List[V]) Remove(e *Element[V]) V {
+	if e.list == l {
+		l.remove(e)
+	}
+
+	return e.Value
+}
+
+// RemoveDetach removes e from l if e is an element of list l and returns e
+// detached from any list, so that it can be given a new Value and re-inserted
+// with PushFrontElement or PushBackElement instead of allocating a new
+// element. It returns nil if e is not an element of l. The element must not
+// be nil.
+func (l *List[V]) RemoveDetach(e *Element[V]) *Element[V] {
+	if e.list != l {
+		return nil
+	}
+
+	l.remove(e)
+
+	return e
+}
+
+// PushFrontElement inserts the detached element e at the front of list l.
+// If e is still an element of a list, the list is not modified. The element
+// must not be nil.
+func (l *List[V]) PushFrontElement(e *Element[V]) {
+	if e.list != nil {
+		return
+	}
+
+	l.lazyInit()
+	l.insert(e, &l.root)
+}
+
+// PushBackElement inserts the detached element e at the back of list l.
+// If e is still an element of a list, the list is not modified. The element
+// must not be nil.
+func (l *List[V]) PushBackElement(e *Element[V]) {
+	if e.list != nil {
+		return
+	}
+
+	l.lazyInit()
+	l.insert(e, l.root.prev)
+}
+
+// PushFront inserts a new element e with value v at the front of list l and
+// returns e.
+func (l *List[V]) PushFront(v V) *Element[V] {
+	l.lazyInit()
+
+	return l.insertValue(v, &l.root)
+}
+
+// PushBack inserts a new element e with value v at the back of list l and
+// returns e.
+func (l *List[V]) PushBack(v V) *Element[V] {
+	l.lazyInit()
+
+	return l.insertValue(v, l.root.prev)
+}
+
+// InsertBefore inserts a new element e with value v immediately before mark
+// and returns e. If mark is not an element of l, the list is not modified.
+// The mark must not be nil.
+func (l *List[V]) InsertBefore(v V, mark *Element[V]) *Element[V] {
+	if mark.list != l {
+		return nil
+	}
+
+	return l.insertValue(v, mark.prev)
+}
+
+// InsertAfter inserts a new element e with value v immediately after mark
+// and returns e. If mark is not an element of l, the list is not modified.
+// The mark must not be nil.
+func (l *List[V]) InsertAfter(v V, mark *Element[V]) *Element[V] {
+	if mark.list != l {
+		return nil
+	}
+
+	return l.insertValue(v, mark)
+}
+
+// MoveToFront moves element e to the front of list l. If e is not an element
+// of l, the list is not modified. The element must not be nil.
+func (l *List[V]) MoveToFront(e *Element[V]) {
+	if e.list != l || l.root.next == e {
+		return
+	}
+
+	l.move(e, &l.root)
+}
+
+// MoveToBack moves element e to the back of list l. If e is not an element
+// of l, the list is not modified. The element must not be nil.
+func (l *List[V]) MoveToBack(e *Element[V]) {
+	if e.list != l || l.root.prev == e {
+		return
+	}
+
+	l.move(e, l.root.prev)
+}
+
+// MoveBefore moves element e to its new position before mark. If e or mark
+// is not an element of l, or e == mark, the list is not modified. The element
+// and mark must not be nil.
+func (l *List[V]) MoveBefore(e, mark *Element[V]) {
+	if e.list != l || e == mark || mark.list != l {
+		return
+	}
+
+	l.move(e, mark.prev)
+}
+
+// MoveAfter moves element e to its new position after mark. If e or mark is
+// not an element of l, or e == mark, the list is not modified. The element
+// and mark must not be nil.
+func (l *List[V]) MoveAfter(e, mark *Element[V]) {
+	if e.list != l || e == mark || mark.list != l {
+		return
+	}
+
+	l.move(e, mark)
+}
+
+// PushBackList moves all elements of other to the back of list l, keeping
+// their order, and leaves other empty. Unlike container/list, elements are
+// spliced rather than copied: no allocations are made and existing *Element
+// references stay valid, now belonging to l. The cost is O(other.Len()) to
+// re-parent the elements. If other is l, the list is not modified.
+func (l *List[V]) PushBackList(other *List[V]) {
+	l.lazyInit()
+	l.splice(other, l.root.prev)
+}
+
+// PushFrontList moves all elements of other to the front of list l, keeping
+// their order, and leaves other empty. See PushBackList for details.
+func (l *List[V]) PushFrontList(other *List[V]) {
+	l.lazyInit()
+	l.splice(other, &l.root)
+}
+
+// lazyInit lazily initializes a zero List value.
+func (l *List[V]) lazyInit() {
+	if l.root.next == nil {
+		l.Init()
+	}
+}
+
+// insert inserts e after at and returns e.
+func (l *List[V]) insert(e, at *Element[V]) *Element[V] {
+	e.prev = at
+	e.next = at.next
+	e.prev.next = e
+	e.next.prev = e
+	e.list = l
+	l.len++
+
+	return e
+}
+
+// insertValue is a convenience wrapper for insert(&Element{Value: v}, at).
+func (l *List[V]) insertValue(v V, at *Element[V]) *Element[V] {
+	return l.insert(&Element[V]{Value: v}, at)
+}
+
+// remove removes e from its list.
+func (l *List[V]) remove(e *Element[V]) {
+	e.prev.next = e.next
+	e.next.prev = e.prev
+	e.next = nil // avoid memory leaks
+	e.prev = nil // avoid memory leaks
+	e.list = nil
+	l.len--
+}
+
+// splice moves all elements of other after at, which must belong to l.
+func (l *List[V]) splice(other *List[V], at *Element[V]) {
+	if other == l || other.len == 0 {
+		return
+	}
+
+	first, last := other.root.next, other.root.prev
+	for e := first; e != &other.root; e = e.next {
+		e.list = l
+	}
+
+	first.prev = at
+	last.next = at.next
+	at.next.prev = last
+	at.next = first
+	l.len += other.len
+
+	other.Init()
+}
+
+// move moves e to next to at.
+func (l *List[V]) move(e, at *Element[V]) {
+	if e == at {
+		return
+	}
+
+	e.prev.next = e.next
+	e.next.prev = e.prev
+
+	e.prev = at
+	e.next = at.next
+	e.prev.next = e
+	e.next.prev = e
+}
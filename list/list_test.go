@@ -0,0 +1,417 @@
+package list
+
+import (
+	"slices"
+	"testing"
+)
+
+// checkList verifies the structure of l and that it holds want, front to back.
+func checkList[V comparable](t *testing.T, l *List[V], want ...V) {
+	t.Helper()
+
+	if l.Len() != len(want) {
+		t.Fatalf("Len: want %d, got %d", len(want), l.Len())
+	}
+
+	if len(want) == 0 {
+		if l.Front() != nil || l.Back() != nil {
+			t.Fatal("empty list has Front or Back")
+		}
+
+		return
+	}
+
+	root := &l.root
+	i := 0
+
+	for e := root.next; e != root; e, i = e.next, i+1 {
+		if e.list != l {
+			t.Fatalf("element %d: list = %p, want %p", i, e.list, l)
+		}
+
+		if e.next.prev != e || e.prev.next != e {
+			t.Fatalf("element %d: broken links", i)
+		}
+	}
+
+	if got := slices.Collect(l.All()); !slices.Equal(got, want) {
+		t.Fatalf("All: want %v, got %v", want, got)
+	}
+
+	reversed := slices.Clone(want)
+	slices.Reverse(reversed)
+
+	if got := slices.Collect(l.Backward()); !slices.Equal(got, reversed) {
+		t.Fatalf("Backward: want %v, got %v", reversed, got)
+	}
+
+	if l.Front().Prev() != nil || l.Back().Next() != nil {
+		t.Fatal("Front has Prev or Back has Next")
+	}
+}
+
+func TestZeroValue(t *testing.T) {
+	t.Parallel()
+
+	var l1, l2, l3 List[int]
+
+	l1.PushFront(1)
+	checkList(t, &l1, 1)
+
+	l2.PushBack(1)
+	checkList(t, &l2, 1)
+
+	checkList(t, &l3)
+}
+
+func TestInit(t *testing.T) {
+	t.Parallel()
+
+	l := New[int]()
+	l.PushBack(1)
+	l.PushBack(2)
+
+	if l.Init() != l {
+		t.Fatal("Init did not return the list")
+	}
+
+	checkList(t, l)
+
+	l.PushBack(3)
+	checkList(t, l, 3)
+}
+
+func TestPushAndRemove(t *testing.T) {
+	t.Parallel()
+
+	l := New[int]()
+	checkList(t, l)
+
+	e2 := l.PushFront(2)
+	e1 := l.PushFront(1)
+	e3 := l.PushBack(3)
+	checkList(t, l, 1, 2, 3)
+
+	if l.Front() != e1 || l.Back() != e3 || e1.Next() != e2 || e3.Prev() != e2 {
+		t.Fatal("Front/Back/Next/Prev do not match pushed elements")
+	}
+
+	if v := l.Remove(e2); v != 2 {
+		t.Fatalf("Remove: want 2, got %d", v)
+	}
+
+	checkList(t, l, 1, 3)
+
+	if e2.Next() != nil || e2.Prev() != nil {
+		t.Fatal("removed element still has neighbours")
+	}
+
+	// Removing twice is a no-op.
+	l.Remove(e2)
+	checkList(t, l, 1, 3)
+
+	l.Remove(e1)
+	l.Remove(e3)
+	checkList(t, l)
+}
+
+func TestRemoveForeignElement(t *testing.T) {
+	t.Parallel()
+
+	l1, l2 := New[int](), New[int]()
+	l1.PushBack(1)
+	l1.PushBack(2)
+
+	e := l2.PushBack(3)
+	l2.PushBack(4)
+
+	if v := l1.Remove(e); v != 3 {
+		t.Fatalf("Remove: want value 3, got %d", v)
+	}
+
+	checkList(t, l1, 1, 2)
+	checkList(t, l2, 3, 4)
+}
+
+func TestInsert(t *testing.T) {
+	t.Parallel()
+
+	l := New[int]()
+	mark := l.PushBack(2)
+
+	l.InsertBefore(1, mark)
+	l.InsertAfter(4, mark)
+	l.InsertAfter(3, mark)
+	checkList(t, l, 1, 2, 3, 4)
+
+	other := New[int]()
+	foreign := other.PushBack(9)
+
+	if e := l.InsertBefore(0, foreign); e != nil {
+		t.Error("InsertBefore with foreign mark returned an element")
+	}
+
+	if e := l.InsertAfter(0, foreign); e != nil {
+		t.Error("InsertAfter with foreign mark returned an element")
+	}
+
+	checkList(t, l, 1, 2, 3, 4)
+	checkList(t, other, 9)
+}
+
+func TestMove(t *testing.T) {
+	t.Parallel()
+
+	l := New[int]()
+	e1 := l.PushBack(1)
+	e2 := l.PushBack(2)
+	e3 := l.PushBack(3)
+	e4 := l.PushBack(4)
+
+	l.MoveToFront(e3)
+	checkList(t, l, 3, 1, 2, 4)
+
+	l.MoveToFront(e3)
+	checkList(t, l, 3, 1, 2, 4)
+
+	l.MoveToBack(e1)
+	checkList(t, l, 3, 2, 4, 1)
+
+	l.MoveToBack(e1)
+	checkList(t, l, 3, 2, 4, 1)
+
+	l.MoveBefore(e1, e3)
+	checkList(t, l, 1, 3, 2, 4)
+
+	l.MoveAfter(e1, e4)
+	checkList(t, l, 3, 2, 4, 1)
+
+	l.MoveBefore(e2, e2)
+	l.MoveAfter(e2, e2)
+	checkList(t, l, 3, 2, 4, 1)
+
+	// Moving next to an element's current neighbour keeps its position.
+	l.MoveAfter(e2, e3)
+	l.MoveBefore(e2, e4)
+	checkList(t, l, 3, 2, 4, 1)
+}
+
+func TestMoveForeignElement(t *testing.T) {
+	t.Parallel()
+
+	l1, l2 := New[int](), New[int]()
+	e1 := l1.PushBack(1)
+	l1.PushBack(2)
+
+	e3 := l2.PushBack(3)
+	l2.PushBack(4)
+
+	l1.MoveToFront(e3)
+	l1.MoveToBack(e3)
+	l1.MoveBefore(e3, e1)
+	l1.MoveAfter(e3, e1)
+	l1.MoveBefore(e1, e3)
+	l1.MoveAfter(e1, e3)
+
+	checkList(t, l1, 1, 2)
+	checkList(t, l2, 3, 4)
+}
+
+func TestIteratorStop(t *testing.T) {
+	t.Parallel()
+
+	l := New[int]()
+	for i := range 5 {
+		l.PushBack(i)
+	}
+
+	var got []int
+
+	for v := range l.All() {
+		if v == 2 {
+			break
+		}
+
+		got = append(got, v)
+	}
+
+	if !slices.Equal(got, []int{0, 1}) {
+		t.Errorf("All with break: want [0 1], got %v", got)
+	}
+
+	got = nil
+
+	for v := range l.Backward() {
+		if v == 2 {
+			break
+		}
+
+		got = append(got, v)
+	}
+
+	if !slices.Equal(got, []int{4, 3}) {
+		t.Errorf("Backward with break: want [4 3], got %v", got)
+	}
+}
+
+func TestPushBackList(t *testing.T) {
+	t.Parallel()
+
+	l1, l2 := New[int](), New[int]()
+	l1.PushBack(1)
+	l1.PushBack(2)
+
+	e3 := l2.PushBack(3)
+	e4 := l2.PushBack(4)
+
+	l1.PushBackList(l2)
+	checkList(t, l1, 1, 2, 3, 4)
+	checkList(t, l2)
+
+	if e3.list != l1 || e4.list != l1 {
+		t.Fatal("spliced elements were not re-parented")
+	}
+
+	// Spliced elements are usable through their new list.
+	l1.MoveToFront(e4)
+	checkList(t, l1, 4, 1, 2, 3)
+
+	// other is reusable after being emptied.
+	l2.PushBack(5)
+	checkList(t, l2, 5)
+}
+
+func TestPushFrontList(t *testing.T) {
+	t.Parallel()
+
+	l1, l2 := New[int](), New[int]()
+	l1.PushBack(3)
+	l2.PushBack(1)
+	l2.PushBack(2)
+
+	l1.PushFrontList(l2)
+	checkList(t, l1, 1, 2, 3)
+	checkList(t, l2)
+}
+
+func TestSpliceIntoZeroValue(t *testing.T) {
+	t.Parallel()
+
+	var back, front List[int]
+
+	other := New[int]()
+	other.PushBack(1)
+	other.PushBack(2)
+
+	back.PushBackList(other)
+	checkList(t, &back, 1, 2)
+	checkList(t, other)
+
+	other.PushBack(3)
+	front.PushFrontList(other)
+	checkList(t, &front, 3)
+}
+
+func TestSpliceEmptyAndSelf(t *testing.T) {
+	t.Parallel()
+
+	l := New[int]()
+	l.PushBack(1)
+	l.PushBack(2)
+
+	l.PushBackList(l)
+	l.PushFrontList(l)
+	checkList(t, l, 1, 2)
+
+	var empty List[int]
+
+	l.PushBackList(&empty)
+	l.PushFrontList(New[int]())
+	checkList(t, l, 1, 2)
+	checkList(t, &empty)
+}
+
+func TestRemoveDetach(t *testing.T) {
+	t.Parallel()
+
+	l := New[int]()
+	l.PushBack(1)
+	e := l.PushBack(2)
+	l.PushBack(3)
+
+	if got := l.RemoveDetach(e); got != e {
+		t.Fatalf("RemoveDetach: want %p, got %p", e, got)
+	}
+
+	checkList(t, l, 1, 3)
+
+	if e.list != nil || e.Next() != nil || e.Prev() != nil {
+		t.Fatal("detached element is still linked")
+	}
+
+	if got := l.RemoveDetach(e); got != nil {
+		t.Fatal("RemoveDetach of already detached element returned it")
+	}
+}
+
+func TestRemoveDetachForeignElement(t *testing.T) {
+	t.Parallel()
+
+	l1, l2 := New[int](), New[int]()
+	l1.PushBack(1)
+	e := l2.PushBack(2)
+
+	if got := l1.RemoveDetach(e); got != nil {
+		t.Fatalf("RemoveDetach of foreign element: want nil, got %p", got)
+	}
+
+	checkList(t, l1, 1)
+	checkList(t, l2, 2)
+}
+
+func TestPushElementReuse(t *testing.T) {
+	t.Parallel()
+
+	l := New[int]()
+	l.PushBack(1)
+	l.PushBack(2)
+	e := l.PushBack(3)
+
+	// Evict the back element and reuse it for a new value at the front, as an
+	// LRU cache does.
+	d := l.RemoveDetach(e)
+	d.Value = 4
+	l.PushFrontElement(d)
+	checkList(t, l, 4, 1, 2)
+
+	if l.Front() != e {
+		t.Fatal("PushFrontElement allocated a new element")
+	}
+
+	l.PushBackElement(l.RemoveDetach(e))
+	checkList(t, l, 1, 2, 4)
+
+	// A caller-allocated element is detached too.
+	var z List[int]
+
+	z.PushFrontElement(&Element[int]{Value: 5})
+	z.PushBackElement(&Element[int]{Value: 6})
+	checkList(t, &z, 5, 6)
+}
+
+func TestPushAttachedElement(t *testing.T) {
+	t.Parallel()
+
+	l1, l2 := New[int](), New[int]()
+	e1 := l1.PushBack(1)
+	l1.PushBack(2)
+	e3 := l2.PushBack(3)
+
+	l1.PushFrontElement(e1)
+	l1.PushBackElement(e1)
+	l1.PushFrontElement(e3)
+	l1.PushBackElement(e3)
+
+	checkList(t, l1, 1, 2)
+	checkList(t, l2, 3)
+}
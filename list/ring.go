@@ -0,0 +1,118 @@
+package list
+
+import "iter"
+
+// Ring is a fixed-capacity FIFO buffer. Once full, pushing a value overwrites
+// the oldest one and returns it to the caller.
+//
+// Unlike List, the zero value is not usable; create rings with NewRing.
+type Ring[V any] struct {
+	buf  []V
+	head int // index of the oldest value
+	len  int
+}
+
+// NewRing returns an empty ring holding at most n values. It panics if n < 1.
+func NewRing[V any](n int) *Ring[V] {
+	if n < 1 {
+		panic("list: ring capacity must be positive")
+	}
+
+	return &Ring[V]{buf: make([]V, n)}
+}
+
+// Len returns the number of values in the ring.
+func (r *Ring[V]) Len() int {
+	return r.len
+}
+
+// Cap returns the maximum number of values the ring holds.
+func (r *Ring[V]) Cap() int {
+	return len(r.buf)
+}
+
+// Full reports whether the next Push overwrites a value.
+func (r *Ring[V]) Full() bool {
+	return r.len == len(r.buf)
+}
+
+// Push appends v as the newest value. If the ring was full, the oldest value
+// is overwritten and returned with ok set to true.
+func (r *Ring[V]) Push(v V) (old V, ok bool) {
+	if r.Full() {
+		old, r.buf[r.head] = r.buf[r.head], v
+		r.head = r.index(1)
+
+		return old, true
+	}
+
+	r.buf[r.index(r.len)] = v
+	r.len++
+
+	return old, false
+}
+
+// Pop removes and returns the oldest value. It reports false if the ring is
+// empty.
+func (r *Ring[V]) Pop() (V, bool) {
+	var zero V
+
+	if r.len == 0 {
+		return zero, false
+	}
+
+	v := r.buf[r.head]
+	r.buf[r.head] = zero // release the reference
+	r.head = r.index(1)
+	r.len--
+
+	return v, true
+}
+
+// Front returns the oldest value without removing it. It reports false if the
+// ring is empty.
+func (r *Ring[V]) Front() (V, bool) {
+	if r.len == 0 {
+		var zero V
+
+		return zero, false
+	}
+
+	return r.buf[r.head], true
+}
+
+// Back returns the newest value without removing it. It reports false if the
+// ring is empty.
+func (r *Ring[V]) Back() (V, bool) {
+	if r.len == 0 {
+		var zero V
+
+		return zero, false
+	}
+
+	return r.buf[r.index(r.len-1)], true
+}
+
+// All returns an iterator over the values from oldest to newest.
+// The ring must not be modified during iteration.
+func (r *Ring[V]) All() iter.Seq[V] {
+	return func(yield func(V) bool) {
+		for i := range r.len {
+			if !yield(r.buf[r.index(i)]) {
+				return
+			}
+		}
+	}
+}
+
+// Clear removes all values from the ring.
+func (r *Ring[V]) Clear() {
+	clear(r.buf)
+	r.head = 0
+	r.len = 0
+}
+
+// index returns the buffer position of the i-th oldest value.
+func (r *Ring[V]) index(i int) int {
+	return (r.head + i) % len(r.buf)
+}
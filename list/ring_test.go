@@ -0,0 +1,163 @@
+package list
+
+import (
+	"slices"
+	"testing"
+)
+
+func checkRing(t *testing.T, r *Ring[int], want ...int) {
+	t.Helper()
+
+	if r.Len() != len(want) {
+		t.Fatalf("Len: want %d, got %d", len(want), r.Len())
+	}
+
+	if got := slices.Collect(r.All()); !slices.Equal(got, want) {
+		t.Fatalf("All: want %v, got %v", want, got)
+	}
+
+	front, okFront := r.Front()
+	back, okBack := r.Back()
+
+	if len(want) == 0 {
+		if okFront || okBack {
+			t.Fatal("empty ring reported Front or Back")
+		}
+
+		return
+	}
+
+	if !okFront || front != want[0] {
+		t.Fatalf("Front: want %d, got %d (%t)", want[0], front, okFront)
+	}
+
+	if !okBack || back != want[len(want)-1] {
+		t.Fatalf("Back: want %d, got %d (%t)", want[len(want)-1], back, okBack)
+	}
+}
+
+func TestNewRingPanics(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		if recover() == nil {
+			t.Error("NewRing(0) did not panic")
+		}
+	}()
+
+	NewRing[int](0)
+}
+
+func TestRingPushOverwrite(t *testing.T) {
+	t.Parallel()
+
+	r := NewRing[int](3)
+	checkRing(t, r)
+
+	for i := 1; i <= 3; i++ {
+		if _, ok := r.Push(i); ok {
+			t.Fatalf("Push(%d) overwrote a value before the ring was full", i)
+		}
+	}
+
+	if !r.Full() || r.Cap() != 3 {
+		t.Fatalf("want full ring of capacity 3, got Full=%t Cap=%d", r.Full(), r.Cap())
+	}
+
+	checkRing(t, r, 1, 2, 3)
+
+	for i, want := range []int{1, 2, 3, 4} {
+		old, ok := r.Push(i + 4)
+		if !ok || old != want {
+			t.Fatalf("Push(%d): want overwritten %d, got %d (%t)", i+4, want, old, ok)
+		}
+	}
+
+	// The head has wrapped around the buffer more than once.
+	checkRing(t, r, 5, 6, 7)
+}
+
+func TestRingPop(t *testing.T) {
+	t.Parallel()
+
+	r := NewRing[int](3)
+
+	if _, ok := r.Pop(); ok {
+		t.Fatal("Pop on empty ring reported true")
+	}
+
+	r.Push(1)
+	r.Push(2)
+	r.Push(3)
+	r.Push(4) // overwrites 1, head now at index 1
+
+	if v, ok := r.Pop(); !ok || v != 2 {
+		t.Fatalf("Pop: want 2, got %d (%t)", v, ok)
+	}
+
+	checkRing(t, r, 3, 4)
+
+	// The freed slot is behind the head; the next push wraps into it.
+	if _, ok := r.Push(5); ok {
+		t.Fatal("Push after Pop overwrote a value")
+	}
+
+	checkRing(t, r, 3, 4, 5)
+
+	for _, want := range []int{3, 4, 5} {
+		if v, ok := r.Pop(); !ok || v != want {
+			t.Fatalf("Pop: want %d, got %d (%t)", want, v, ok)
+		}
+	}
+
+	checkRing(t, r)
+}
+
+func TestRingPopReleasesValue(t *testing.T) {
+	t.Parallel()
+
+	r := NewRing[*int](2)
+	r.Push(new(int))
+	r.Pop()
+
+	if r.buf[0] != nil {
+		t.Error("Pop kept a reference to the removed value")
+	}
+}
+
+func TestRingClear(t *testing.T) {
+	t.Parallel()
+
+	r := NewRing[int](2)
+	r.Push(1)
+	r.Push(2)
+	r.Push(3)
+	r.Clear()
+	checkRing(t, r)
+
+	r.Push(4)
+	checkRing(t, r, 4)
+}
+
+func TestRingAllStop(t *testing.T) {
+	t.Parallel()
+
+	r := NewRing[int](3)
+	for i := range 5 {
+		r.Push(i)
+	}
+
+	var got []int
+
+	for v := range r.All() {
+		if v == 4 {
+			break
+		}
+
+		got = append(got, v)
+	}
+
+	if !slices.Equal(got, []int{2, 3}) {
+		t.Errorf("All with break: want [2 3], got %v", got)
+	}
+}
@@ -0,0 +1,382 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"slices"
+	"sync"
+	"time"
+)
+
+// errGoexit is the result of a load whose getter called runtime.Goexit.
+var errGoexit = errors.New("cache: getter called runtime.Goexit")
+
+// PanicError is the error of a load whose getter panicked. The goroutine that
+// ran the getter panics again with the original value; every other caller
+// waiting on the load receives a *PanicError.
+type PanicError struct {
+	Value any    // the value passed to panic
+	Stack []byte // the stack of the panicking goroutine
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("cache: getter panicked: %v\n\n%s", e.Value, e.Stack)
+}
+
+// Unwrap returns the panic value if it is an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+
+	return err
+}
+
+// Loading is a cache that loads missing values through a Getter and stores
+// them in an inner cache, created by NewLoading.
+//
+// Concurrent Gets of the same missing key share a single call to the getter:
+// the first caller runs it with its own context, and the others wait for the
+// result. This collapses bursts of misses into one load from the origin even
+// for backends, such as remote caches, that cannot coalesce by themselves.
+type Loading[K comparable, V any] struct {
+	inner  Interface[K, V]
+	getter Getter[K, V]
+	loads  map[K]*load[V]
+	locks  map[K]*keyLock
+	mu     sync.Mutex
+}
+
+// keyLock serializes the work on one key. It is held by sending to ch.
+type keyLock struct {
+	ch   chan struct{}
+	refs int // holders and waiters, guarded by Loading.mu
+}
+
+// load is a getter call in progress.
+type load[V any] struct {
+	started time.Time
+	done    chan struct{} // closed when value and err are set
+	value   V
+	err     error
+	waiters int  // callers waiting on done, guarded by Loading.mu
+	retry   bool // the leader gave up on behalf of the waiters
+}
+
+// LoadInfo describes a load in progress, as reported by Loading.InFlight.
+type LoadInfo[K comparable] struct {
+	Key     K
+	Started time.Time // when the load started
+	Waiters int       // callers waiting on the load besides the one running it
+}
+
+var (
+	_ Interface[string, any] = (*Loading[string, any])(nil)
+	_ TTLSetter[string, any] = (*Loading[string, any])(nil)
+)
+
+// NewLoading returns a cache that serves values from inner and loads misses
+// through getter.
+func NewLoading[K comparable, V any](inner Interface[K, V], getter Getter[K, V]) *Loading[K, V] {
+	return &Loading[K, V]{
+		inner:  inner,
+		getter: getter,
+		loads:  make(map[K]*load[V]),
+		locks:  make(map[K]*keyLock),
+	}
+}
+
+// Get returns the value of key from the inner cache. On a miss, it loads the
+// value through the getter, sharing the load with concurrent Gets of key, and
+// stores it in the inner cache. Errors of the getter are returned unchanged.
+//
+// If ctx is done while waiting for a load started by another caller, Get
+// returns ctx.Err() and leaves the load running for the others. Conversely,
+// if the getter fails after the context of the caller running it is done,
+// that caller gets the error, but one of the waiters runs the getter again
+// with its own context rather than all of them failing.
+//
+// Storing the loaded value is best effort: if the inner cache fails to store
+// it, Get still returns it, and the next Get loads again. A Set or Delete of
+// key made while it is loading may be overwritten by the loaded value.
+func (l *Loading[K, V]) Get(ctx context.Context, key K) (V, error) {
+	v, err := l.inner.Get(ctx, key)
+	if !errors.Is(err, ErrNotFound) {
+		return v, err
+	}
+
+	return l.load(ctx, key, l.getter)
+}
+
+// GetOrLoad is like Get, but loads a missing key through loader instead of
+// the getter of l, e.g. when loading needs parameters of the call site. The
+// load is still shared: if key is already loading, through Get or another
+// GetOrLoad, the caller waits for that load and loader is not called.
+func (l *Loading[K, V]) GetOrLoad(ctx context.Context, key K, loader func(ctx context.Context) (V, error)) (V, error) {
+	v, err := l.inner.Get(ctx, key)
+	if !errors.Is(err, ErrNotFound) {
+		return v, err
+	}
+
+	return l.load(ctx, key, func(ctx context.Context, _ K) (V, error) {
+		return loader(ctx)
+	})
+}
+
+// Set stores value in the inner cache.
+func (l *Loading[K, V]) Set(ctx context.Context, key K, value V) error {
+	return l.inner.Set(ctx, key, value)
+}
+
+// SetWithTTL stores value in the inner cache, expiring it after ttl if the
+// inner cache implements TTLSetter.
+func (l *Loading[K, V]) SetWithTTL(ctx context.Context, key K, value V, ttl time.Duration) error {
+	return setWithTTL(ctx, l.inner, key, value, ttl)
+}
+
+// Delete removes key from the inner cache.
+func (l *Loading[K, V]) Delete(ctx context.Context, key K) error {
+	return l.inner.Delete(ctx, key)
+}
+
+// Len returns the number of entries in the inner cache.
+func (l *Loading[K, V]) Len() int {
+	return l.inner.Len()
+}
+
+// Do runs fn while holding the lock of key, so that only one goroutine works
+// on key at a time. The lock is shared with getter loads: fn never runs
+// concurrently with a load of key, and a load that waited for fn first looks
+// key up again, so a value stored by fn is not loaded once more.
+//
+// If ctx is done before the lock is acquired, Do returns ctx.Err() without
+// running fn. Otherwise it returns the error of fn. fn must not load key
+// through l, which would wait for fn to return.
+func (l *Loading[K, V]) Do(ctx context.Context, key K, fn func(ctx context.Context) error) error {
+	unlock, _, err := l.lock(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	defer unlock()
+
+	return fn(ctx)
+}
+
+// ScheduleRefresh loads key through the getter right away and then every
+// interval, whether or not it is looked up, so that it is never stale or
+// missing for long. Refreshes share loads with concurrent Gets of key, but
+// unlike them do not look in the inner cache first.
+//
+// A failed refresh, including one whose getter panics, leaves the stored
+// value as it is until the next. Refreshing stops when ctx is done or stop is
+// called; stop waits for a refresh in progress to return. ScheduleRefresh
+// panics if interval is not positive.
+func (l *Loading[K, V]) ScheduleRefresh(ctx context.Context, key K, interval time.Duration) (stop func()) {
+	ticker := time.NewTicker(interval)
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		defer ticker.Stop()
+
+		for {
+			l.refresh(ctx, key)
+
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// refresh loads key through the getter, dropping failures: there is no
+// caller to report them to.
+func (l *Loading[K, V]) refresh(ctx context.Context, key K) {
+	defer func() { _ = recover() }()
+
+	_, _ = l.load(ctx, key, l.getter)
+}
+
+// InFlight returns the loads in progress, oldest first, e.g. to tell during
+// an incident whether callers are stuck waiting on the origin. A load retried
+// by a waiter after its first run failed counts from the retry.
+func (l *Loading[K, V]) InFlight() []LoadInfo[K] {
+	l.mu.Lock()
+
+	loads := make([]LoadInfo[K], 0, len(l.loads))
+	for key, ld := range l.loads {
+		loads = append(loads, LoadInfo[K]{Key: key, Started: ld.started, Waiters: ld.waiters})
+	}
+
+	l.mu.Unlock()
+
+	slices.SortFunc(loads, func(a, b LoadInfo[K]) int {
+		return a.Started.Compare(b.Started)
+	})
+
+	return loads
+}
+
+// load joins the load of key in progress, or starts one with getter.
+func (l *Loading[K, V]) load(ctx context.Context, key K, getter Getter[K, V]) (V, error) {
+	for {
+		l.mu.Lock()
+
+		ld, ok := l.loads[key]
+		if !ok {
+			ld = &load[V]{started: time.Now(), done: make(chan struct{})}
+			l.loads[key] = ld
+			l.mu.Unlock()
+
+			l.run(ctx, key, getter, ld)
+
+			return ld.value, ld.err
+		}
+
+		ld.waiters++
+		l.mu.Unlock()
+
+		select {
+		case <-ld.done:
+			if !ld.retry {
+				return ld.value, ld.err
+			}
+		case <-ctx.Done():
+			l.mu.Lock()
+			ld.waiters--
+			l.mu.Unlock()
+
+			var zero V
+
+			return zero, ctx.Err()
+		}
+	}
+}
+
+// run calls getter for ld and stores its result. If getter panics, the
+// waiters receive a *PanicError and the panic continues in the caller.
+func (l *Loading[K, V]) run(ctx context.Context, key K, getter Getter[K, V], ld *load[V]) {
+	returned := false
+
+	defer func() {
+		if returned {
+			return
+		}
+
+		r := recover()
+		if r == nil {
+			// runtime.Goexit, which continues once the waiters are released.
+			ld.err = errGoexit
+			l.finish(key, ld)
+
+			return
+		}
+
+		ld.err = &PanicError{Value: r, Stack: debug.Stack()}
+		l.finish(key, ld)
+
+		panic(r)
+	}()
+
+	loaded := false
+	ld.value, loaded, ld.err = l.call(ctx, key, getter)
+	returned = true
+
+	if loaded {
+		_ = l.inner.Set(ctx, key, ld.value)
+	}
+
+	// A failure after the leader's context is done is most likely caused by
+	// it, and says nothing about the waiters' chances.
+	l.mu.Lock()
+	ld.retry = ld.err != nil && ctx.Err() != nil && ld.waiters > 0
+	l.mu.Unlock()
+
+	l.finish(key, ld)
+}
+
+// call runs getter under the lock of key and reports whether it loaded a
+// value. If it had to wait for the lock, it first looks key up again in case
+// the holder stored it.
+func (l *Loading[K, V]) call(ctx context.Context, key K, getter Getter[K, V]) (V, bool, error) {
+	var zero V
+
+	unlock, waited, err := l.lock(ctx, key)
+	if err != nil {
+		return zero, false, err
+	}
+
+	defer unlock()
+
+	if waited {
+		if v, err := l.inner.Get(ctx, key); !errors.Is(err, ErrNotFound) {
+			return v, false, err
+		}
+	}
+
+	v, err := getter(ctx, key)
+
+	return v, err == nil, err
+}
+
+// lock acquires the lock of key and returns the function releasing it. It
+// reports whether the lock was held by someone else at first.
+func (l *Loading[K, V]) lock(ctx context.Context, key K) (func(), bool, error) {
+	l.mu.Lock()
+
+	kl, ok := l.locks[key]
+	if !ok {
+		kl = &keyLock{ch: make(chan struct{}, 1)}
+		l.locks[key] = kl
+	}
+
+	kl.refs++
+	l.mu.Unlock()
+
+	release := func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		if kl.refs--; kl.refs == 0 {
+			delete(l.locks, key)
+		}
+	}
+
+	unlock := func() {
+		<-kl.ch
+		release()
+	}
+
+	select {
+	case kl.ch <- struct{}{}:
+		return unlock, false, nil
+	default:
+	}
+
+	select {
+	case kl.ch <- struct{}{}:
+		return unlock, true, nil
+	case <-ctx.Done():
+		release()
+
+		return nil, false, ctx.Err()
+	}
+}
+
+// finish releases the waiters of ld.
+func (l *Loading[K, V]) finish(key K, ld *load[V]) {
+	l.mu.Lock()
+	delete(l.loads, key)
+	l.mu.Unlock()
+
+	close(ld.done)
+}
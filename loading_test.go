@@ -0,0 +1,875 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.expect.digital/cache"
+	"go.expect.digital/cache/cachetest"
+)
+
+var errBoom = errors.New("boom")
+
+// waitGets waits until f has recorded n Gets, so that callers of a Loading
+// over f have missed and are about to join a load.
+func waitGets[V any](t *testing.T, f *cachetest.Fake[string, V], n int) {
+	t.Helper()
+
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		gets := 0
+
+		for _, call := range f.Calls() {
+			if call.Op == cachetest.OpGet {
+				gets++
+			}
+		}
+
+		if gets >= n {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("want %d Gets, got %d", n, gets)
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	// Let the callers get from the miss to the load.
+	time.Sleep(10 * time.Millisecond)
+}
+
+func TestLoadingConformance(t *testing.T) {
+	t.Parallel()
+
+	cachetest.Run(t, func(*testing.T) cache.Interface[string, string] {
+		return cache.NewLoading(cachetest.NewFake[string, string](), func(context.Context, string) (string, error) {
+			return "", cache.ErrNotFound
+		})
+	})
+}
+
+func TestLoadingLoadsAndStores(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	inner := cachetest.NewFake[string, string]()
+
+	var calls atomic.Int32
+
+	l := cache.NewLoading(inner, func(_ context.Context, key string) (string, error) {
+		calls.Add(1)
+
+		return "value of " + key, nil
+	})
+
+	for range 2 {
+		if v, err := l.Get(ctx, "a"); err != nil || v != "value of a" {
+			t.Fatalf("Get: want %q, got %q, %v", "value of a", v, err)
+		}
+	}
+
+	if calls.Load() != 1 {
+		t.Errorf("getter calls: want 1, got %d", calls.Load())
+	}
+
+	if v, err := inner.Get(ctx, "a"); err != nil || v != "value of a" {
+		t.Errorf("inner: want loaded value, got %q, %v", v, err)
+	}
+}
+
+func TestLoadingCoalesces(t *testing.T) {
+	t.Parallel()
+
+	const callers = 10
+
+	ctx := context.Background()
+	inner := cachetest.NewFake[string, int]()
+
+	// Failing to store makes every load visible: a caller that missed the
+	// shared load would have to call the getter again.
+	inner.FailOn(cachetest.OpSet, "a", errBoom)
+
+	var calls atomic.Int32
+
+	release := make(chan struct{})
+	l := cache.NewLoading(inner, func(context.Context, string) (int, error) {
+		calls.Add(1)
+		<-release
+
+		return 1, nil
+	})
+
+	var wg sync.WaitGroup
+
+	for range callers {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			if v, err := l.Get(ctx, "a"); err != nil || v != 1 {
+				t.Errorf("Get: want 1, got %d, %v", v, err)
+			}
+		}()
+	}
+
+	waitGets(t, inner, callers)
+	close(release)
+	wg.Wait()
+
+	if calls.Load() != 1 {
+		t.Errorf("getter calls: want 1, got %d", calls.Load())
+	}
+}
+
+func TestLoadingGetterError(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	inner := cachetest.NewFake[string, int]()
+
+	var calls atomic.Int32
+
+	l := cache.NewLoading(inner, func(context.Context, string) (int, error) {
+		calls.Add(1)
+
+		return 0, errBoom
+	})
+
+	for range 2 {
+		if _, err := l.Get(ctx, "a"); !errors.Is(err, errBoom) {
+			t.Fatalf("Get: want errBoom, got %v", err)
+		}
+	}
+
+	// Failures are not cached.
+	if calls.Load() != 2 || inner.Len() != 0 {
+		t.Errorf("want 2 getter calls and nothing stored, got %d and %d", calls.Load(), inner.Len())
+	}
+}
+
+func TestLoadingInnerError(t *testing.T) {
+	t.Parallel()
+
+	inner := cachetest.NewFake[string, int]()
+	inner.FailOn(cachetest.OpGet, "a", errBoom)
+
+	l := cache.NewLoading(inner, func(context.Context, string) (int, error) {
+		t.Error("getter called for a failed lookup")
+
+		return 0, nil
+	})
+
+	if _, err := l.Get(context.Background(), "a"); !errors.Is(err, errBoom) {
+		t.Errorf("Get: want errBoom, got %v", err)
+	}
+}
+
+func TestLoadingStoreFailure(t *testing.T) {
+	t.Parallel()
+
+	inner := cachetest.NewFake[string, int]()
+	inner.FailOn(cachetest.OpSet, "a", errBoom)
+
+	l := cache.NewLoading(inner, func(context.Context, string) (int, error) {
+		return 1, nil
+	})
+
+	if v, err := l.Get(context.Background(), "a"); err != nil || v != 1 {
+		t.Errorf("Get: want 1, got %d, %v", v, err)
+	}
+}
+
+func TestLoadingPanic(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	fail := true
+
+	l := cache.NewLoading(cachetest.NewFake[string, int](), func(context.Context, string) (int, error) {
+		if fail {
+			panic(errBoom)
+		}
+
+		return 1, nil
+	})
+
+	func() {
+		defer func() {
+			if r := recover(); r != errBoom {
+				t.Fatalf("recovered: want errBoom, got %v", r)
+			}
+		}()
+
+		_, _ = l.Get(ctx, "a")
+	}()
+
+	// The failed load does not linger.
+	fail = false
+
+	if v, err := l.Get(ctx, "a"); err != nil || v != 1 {
+		t.Errorf("Get after panic: want 1, got %d, %v", v, err)
+	}
+}
+
+// queryError is a structured getter error that callers inspect with
+// errors.As.
+type queryError struct {
+	Query string
+	Err   error
+}
+
+func (e *queryError) Error() string { return e.Query + ": " + e.Err.Error() }
+
+func (e *queryError) Unwrap() error { return e.Err }
+
+// coalesce runs callers concurrent Gets of one key that share a single load
+// through getter, and returns what each of them got: an error, or the value
+// it recovered from a panic.
+func coalesce(t *testing.T, callers int, getter cache.Getter[string, int]) (errs []error, panics []any) {
+	t.Helper()
+
+	inner := cachetest.NewFake[string, int]()
+	release := make(chan struct{})
+
+	l := cache.NewLoading(inner, func(ctx context.Context, key string) (int, error) {
+		<-release
+
+		return getter(ctx, key)
+	})
+
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+	)
+
+	for range callers {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			defer func() {
+				if r := recover(); r != nil {
+					mu.Lock()
+					panics = append(panics, r)
+					mu.Unlock()
+				}
+			}()
+
+			_, err := l.Get(context.Background(), "a")
+
+			mu.Lock()
+			errs = append(errs, err)
+			mu.Unlock()
+		}()
+	}
+
+	waitGets(t, inner, callers)
+	close(release)
+	wg.Wait()
+
+	return errs, panics
+}
+
+func TestLoadingSharedError(t *testing.T) {
+	t.Parallel()
+
+	want := fmt.Errorf("load user: %w", &queryError{Query: "SELECT", Err: errBoom})
+
+	errs, _ := coalesce(t, 5, func(context.Context, string) (int, error) {
+		return 0, want
+	})
+
+	if len(errs) != 5 {
+		t.Fatalf("errors: want 5, got %d", len(errs))
+	}
+
+	// The leader and the waiters all get the getter's error itself.
+	for i, err := range errs {
+		if err != want {
+			t.Errorf("caller %d: want the getter error, got %v", i, err)
+		}
+
+		var qe *queryError
+		if !errors.Is(err, errBoom) || !errors.As(err, &qe) || qe.Query != "SELECT" {
+			t.Errorf("caller %d: chain of %v lost errBoom or *queryError", i, err)
+		}
+	}
+}
+
+func TestLoadingSharedPanic(t *testing.T) {
+	t.Parallel()
+
+	value := fmt.Errorf("decode: %w", errBoom)
+
+	errs, panics := coalesce(t, 5, func(context.Context, string) (int, error) {
+		panic(value)
+	})
+
+	// The leader panics with the original value.
+	if len(panics) != 1 || panics[0] != value {
+		t.Fatalf("panics: want the one original value, got %v", panics)
+	}
+
+	if len(errs) != 4 {
+		t.Fatalf("errors: want 4, got %d", len(errs))
+	}
+
+	for i, err := range errs {
+		var pe *cache.PanicError
+		if !errors.As(err, &pe) || pe.Value != value {
+			t.Errorf("caller %d: want *PanicError with the panic value, got %v", i, err)
+		}
+
+		if !errors.Is(err, errBoom) {
+			t.Errorf("caller %d: chain of %v lost errBoom", i, err)
+		}
+	}
+}
+
+func TestLoadingReloadsExpired(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	inner := cachetest.NewFake[string, int]()
+
+	var calls atomic.Int32
+
+	l := cache.NewLoading(inner, func(context.Context, string) (int, error) {
+		return int(calls.Add(1)), nil
+	})
+
+	_ = l.SetWithTTL(ctx, "a", 0, time.Minute)
+	inner.Advance(time.Minute)
+
+	if v, err := l.Get(ctx, "a"); err != nil || v != 1 {
+		t.Errorf("Get of expired entry: want reloaded 1, got %d, %v", v, err)
+	}
+}
+
+func TestLoadingWaiterContext(t *testing.T) {
+	t.Parallel()
+
+	inner := cachetest.NewFake[string, int]()
+	started, release := make(chan struct{}), make(chan struct{})
+
+	l := cache.NewLoading(inner, func(context.Context, string) (int, error) {
+		close(started)
+		<-release
+
+		return 1, nil
+	})
+
+	leader := make(chan error)
+
+	go func() {
+		_, err := l.Get(context.Background(), "a")
+		leader <- err
+	}()
+
+	<-started
+
+	ctx, cancel := context.WithCancel(context.Background())
+	waiter := make(chan error)
+
+	go func() {
+		_, err := l.Get(ctx, "a")
+		waiter <- err
+	}()
+
+	waitGets(t, inner, 2)
+	cancel()
+
+	// The waiter returns while the load is still blocked.
+	if err := <-waiter; !errors.Is(err, context.Canceled) {
+		t.Fatalf("waiter: want Canceled, got %v", err)
+	}
+
+	close(release)
+
+	if err := <-leader; err != nil {
+		t.Fatalf("leader: %v", err)
+	}
+
+	if v, err := inner.Get(context.Background(), "a"); err != nil || v != 1 {
+		t.Errorf("inner after load: want 1, got %d, %v", v, err)
+	}
+}
+
+func TestLoadingLeaderCancelled(t *testing.T) {
+	t.Parallel()
+
+	inner := cachetest.NewFake[string, int]()
+	started := make(chan struct{})
+
+	var calls atomic.Int32
+
+	l := cache.NewLoading(inner, func(ctx context.Context, _ string) (int, error) {
+		if calls.Add(1) == 1 {
+			close(started)
+			<-ctx.Done()
+
+			return 0, ctx.Err()
+		}
+
+		return 2, nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	leader := make(chan error)
+
+	go func() {
+		_, err := l.Get(ctx, "a")
+		leader <- err
+	}()
+
+	<-started
+
+	waiter := make(chan int)
+
+	go func() {
+		v, err := l.Get(context.Background(), "a")
+		if err != nil {
+			t.Errorf("waiter: %v", err)
+		}
+
+		waiter <- v
+	}()
+
+	waitGets(t, inner, 2)
+	cancel()
+
+	if err := <-leader; !errors.Is(err, context.Canceled) {
+		t.Fatalf("leader: want Canceled, got %v", err)
+	}
+
+	// The waiter took over and ran the getter again.
+	if v := <-waiter; v != 2 {
+		t.Fatalf("waiter: want 2, got %d", v)
+	}
+
+	if calls.Load() != 2 {
+		t.Errorf("getter calls: want 2, got %d", calls.Load())
+	}
+}
+
+func TestLoadingLeaderCancelledAlone(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	l := cache.NewLoading(cachetest.NewFake[string, int](), func(ctx context.Context, _ string) (int, error) {
+		calls.Add(1)
+		cancel()
+
+		return 0, ctx.Err()
+	})
+
+	// Nobody else waits, so the getter is not run again.
+	if _, err := l.Get(ctx, "a"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Get: want Canceled, got %v", err)
+	}
+
+	if calls.Load() != 1 {
+		t.Errorf("getter calls: want 1, got %d", calls.Load())
+	}
+}
+
+func TestLoadingDoSerializes(t *testing.T) {
+	t.Parallel()
+
+	l := cache.NewLoading(cachetest.NewFake[string, int](), func(context.Context, string) (int, error) {
+		return 0, nil
+	})
+
+	var (
+		active, peak atomic.Int32
+		wg           sync.WaitGroup
+	)
+
+	for range 8 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			_ = l.Do(context.Background(), "a", func(context.Context) error {
+				n := active.Add(1)
+				if n > peak.Load() {
+					peak.Store(n)
+				}
+
+				time.Sleep(time.Millisecond)
+				active.Add(-1)
+
+				return nil
+			})
+		}()
+	}
+
+	wg.Wait()
+
+	if peak.Load() != 1 {
+		t.Errorf("concurrent Do sections on one key: want 1, got %d", peak.Load())
+	}
+}
+
+func TestLoadingDoKeysIndependent(t *testing.T) {
+	t.Parallel()
+
+	l := cache.NewLoading(cachetest.NewFake[string, int](), func(context.Context, string) (int, error) {
+		return 0, nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Each section waits for the other to start, which only works if they run
+	// at the same time.
+	started := map[string]chan struct{}{"a": make(chan struct{}), "b": make(chan struct{})}
+
+	var wg sync.WaitGroup
+
+	for key, other := range map[string]string{"a": "b", "b": "a"} {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			err := l.Do(ctx, key, func(ctx context.Context) error {
+				close(started[key])
+
+				select {
+				case <-started[other]:
+					return nil
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			})
+			if err != nil {
+				t.Errorf("Do(%s): %v", key, err)
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+func TestLoadingDoBlocksLoad(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	inner := cachetest.NewFake[string, int]()
+
+	var calls atomic.Int32
+
+	l := cache.NewLoading(inner, func(context.Context, string) (int, error) {
+		calls.Add(1)
+
+		return 1, nil
+	})
+
+	got := make(chan int)
+
+	err := l.Do(ctx, "a", func(ctx context.Context) error {
+		go func() {
+			v, err := l.Get(ctx, "a")
+			if err != nil {
+				t.Errorf("Get: %v", err)
+			}
+
+			got <- v
+		}()
+
+		// Store a value while the Get waits for the lock.
+		waitGets(t, inner, 1)
+
+		return inner.Set(ctx, "a", 2)
+	})
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+
+	if v := <-got; v != 2 {
+		t.Errorf("Get: want the value stored by Do, got %d", v)
+	}
+
+	if calls.Load() != 0 {
+		t.Errorf("getter calls: want 0, got %d", calls.Load())
+	}
+}
+
+func TestLoadingDoContext(t *testing.T) {
+	t.Parallel()
+
+	l := cache.NewLoading(cachetest.NewFake[string, int](), func(context.Context, string) (int, error) {
+		return 0, nil
+	})
+
+	release := make(chan struct{})
+	held := make(chan struct{})
+
+	go func() {
+		_ = l.Do(context.Background(), "a", func(context.Context) error {
+			close(held)
+			<-release
+
+			return nil
+		})
+	}()
+
+	<-held
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err := l.Do(ctx, "a", func(context.Context) error {
+		t.Error("fn ran without the lock")
+
+		return nil
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Do: want DeadlineExceeded, got %v", err)
+	}
+
+	close(release)
+
+	// fn errors are returned as they are.
+	if err := l.Do(context.Background(), "a", func(context.Context) error { return errBoom }); !errors.Is(err, errBoom) {
+		t.Errorf("Do: want errBoom, got %v", err)
+	}
+}
+
+func TestLoadingGetOrLoad(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	inner := cachetest.NewFake[string, string]()
+
+	l := cache.NewLoading(inner, func(context.Context, string) (string, error) {
+		t.Error("getter called instead of the loader")
+
+		return "", nil
+	})
+
+	v, err := l.GetOrLoad(ctx, "a", func(context.Context) (string, error) {
+		return "loaded", nil
+	})
+	if err != nil || v != "loaded" {
+		t.Fatalf("GetOrLoad: want %q, got %q, %v", "loaded", v, err)
+	}
+
+	// The loaded value is stored, so the next call does not load.
+	v, err = l.GetOrLoad(ctx, "a", func(context.Context) (string, error) {
+		t.Error("loader called for a stored key")
+
+		return "", nil
+	})
+	if err != nil || v != "loaded" {
+		t.Errorf("GetOrLoad of stored key: want %q, got %q, %v", "loaded", v, err)
+	}
+
+	if _, err := l.GetOrLoad(ctx, "b", func(context.Context) (string, error) { return "", errBoom }); !errors.Is(err, errBoom) {
+		t.Errorf("GetOrLoad: want errBoom, got %v", err)
+	}
+}
+
+func TestLoadingGetOrLoadCoalesces(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	inner := cachetest.NewFake[string, int]()
+	release := make(chan struct{})
+
+	l := cache.NewLoading(inner, func(context.Context, string) (int, error) {
+		<-release
+
+		return 1, nil
+	})
+
+	got := make(chan int)
+
+	go func() {
+		v, err := l.Get(ctx, "a")
+		if err != nil {
+			t.Errorf("Get: %v", err)
+		}
+
+		got <- v
+	}()
+
+	waitGets(t, inner, 1)
+
+	go func() {
+		v, err := l.GetOrLoad(ctx, "a", func(context.Context) (int, error) {
+			t.Error("loader called while key was loading")
+
+			return 2, nil
+		})
+		if err != nil {
+			t.Errorf("GetOrLoad: %v", err)
+		}
+
+		got <- v
+	}()
+
+	waitGets(t, inner, 2)
+	close(release)
+
+	for range 2 {
+		if v := <-got; v != 1 {
+			t.Errorf("want the shared value 1, got %d", v)
+		}
+	}
+}
+
+// waitCalls waits until calls reaches n.
+func waitCalls(t *testing.T, calls *atomic.Int32, n int32) {
+	t.Helper()
+
+	for deadline := time.Now().Add(5 * time.Second); calls.Load() < n; {
+		if time.Now().After(deadline) {
+			t.Fatalf("want %d getter calls, got %d", n, calls.Load())
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestLoadingScheduleRefresh(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	inner := cachetest.NewFake[string, int]()
+
+	var calls atomic.Int32
+
+	l := cache.NewLoading(inner, func(context.Context, string) (int, error) {
+		return int(calls.Add(1)), nil
+	})
+
+	_ = inner.Set(ctx, "a", 0)
+
+	stop := l.ScheduleRefresh(ctx, "a", time.Millisecond)
+
+	// Stored values are refreshed all the same.
+	waitCalls(t, &calls, 3)
+	stop()
+
+	// The last refresh may have been stopped before storing its value.
+	n := calls.Load()
+	if v, err := inner.Get(ctx, "a"); err != nil || v < 2 || v > int(n) {
+		t.Fatalf("stored value: want a refreshed value up to %d, got %d, %v", n, v, err)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+
+	if calls.Load() != n {
+		t.Errorf("getter calls after stop: want %d, got %d", n, calls.Load())
+	}
+}
+
+func TestLoadingScheduleRefreshFailures(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	inner := cachetest.NewFake[string, int]()
+
+	var calls atomic.Int32
+
+	l := cache.NewLoading(inner, func(context.Context, string) (int, error) {
+		switch n := calls.Add(1); n {
+		case 1:
+			panic(errBoom)
+		case 2:
+			return 0, errBoom
+		default:
+			return int(n), nil
+		}
+	})
+
+	stop := l.ScheduleRefresh(ctx, "a", time.Millisecond)
+	defer stop()
+
+	// Neither the panic nor the error ends the refreshing. The fourth call
+	// means the third has stored its value.
+	waitCalls(t, &calls, 4)
+
+	// Cancelling ctx stops it as well as stop does.
+	cancel()
+	stop()
+
+	if v, err := inner.Get(context.Background(), "a"); err != nil || v < 3 {
+		t.Errorf("stored value: want a refreshed value, got %d, %v", v, err)
+	}
+}
+
+func TestLoadingInFlight(t *testing.T) {
+	t.Parallel()
+
+	inner := cachetest.NewFake[string, int]()
+	release := make(chan struct{})
+
+	l := cache.NewLoading(inner, func(context.Context, string) (int, error) {
+		<-release
+
+		return 1, nil
+	})
+
+	if loads := l.InFlight(); len(loads) != 0 {
+		t.Fatalf("InFlight when idle: want none, got %v", loads)
+	}
+
+	before := time.Now()
+
+	var wg sync.WaitGroup
+
+	get := func(key string) {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			_, _ = l.Get(context.Background(), key)
+		}()
+	}
+
+	get("a")
+	waitGets(t, inner, 1)
+
+	for range 3 {
+		get("b")
+	}
+
+	waitGets(t, inner, 4)
+
+	loads := l.InFlight()
+	if len(loads) != 2 {
+		t.Fatalf("InFlight: want 2 loads, got %v", loads)
+	}
+
+	// Oldest first.
+	if loads[0].Key != "a" || loads[0].Waiters != 0 || loads[1].Key != "b" || loads[1].Waiters != 2 {
+		t.Errorf("InFlight: want a with 0 waiters then b with 2, got %+v", loads)
+	}
+
+	if loads[0].Started.Before(before) || loads[1].Started.Before(loads[0].Started) {
+		t.Errorf("InFlight: start times out of order: %+v", loads)
+	}
+
+	close(release)
+	wg.Wait()
+
+	if loads := l.InFlight(); len(loads) != 0 {
+		t.Errorf("InFlight after the loads: want none, got %v", loads)
+	}
+}
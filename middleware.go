@@ -0,0 +1,23 @@
+package cache
+
+import "slices"
+
+// Middleware wraps a cache to add behaviour around its operations, the way
+// HTTP middleware wraps a handler: auth checks on keys, audit logging, fault
+// injection and the like.
+//
+// A middleware usually returns a struct that embeds next and overrides only
+// the methods it is interested in. Capabilities beyond Interface, such as
+// TTLSetter, are only available through the wrapped cache if the middleware
+// provides them.
+type Middleware[K comparable, V any] func(next Interface[K, V]) Interface[K, V]
+
+// WithMiddleware returns c wrapped by mw. The first middleware is the
+// outermost, so it sees each call first and its result last.
+func WithMiddleware[K comparable, V any](c Interface[K, V], mw ...Middleware[K, V]) Interface[K, V] {
+	for _, m := range slices.Backward(mw) {
+		c = m(c)
+	}
+
+	return c
+}
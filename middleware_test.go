@@ -0,0 +1,92 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"go.expect.digital/cache"
+	"go.expect.digital/cache/cachetest"
+)
+
+// tracing records the Gets that pass through it.
+type tracing struct {
+	cache.Interface[string, int]
+
+	name string
+	log  *[]string
+}
+
+func (c tracing) Get(ctx context.Context, key string) (int, error) {
+	*c.log = append(*c.log, c.name)
+
+	return c.Interface.Get(ctx, key)
+}
+
+func TestWithMiddlewareOrder(t *testing.T) {
+	t.Parallel()
+
+	var log []string
+
+	trace := func(name string) cache.Middleware[string, int] {
+		return func(next cache.Interface[string, int]) cache.Interface[string, int] {
+			return tracing{Interface: next, name: name, log: &log}
+		}
+	}
+
+	inner := cachetest.NewFake[string, int]()
+	c := cache.WithMiddleware[string, int](inner, trace("outer"), trace("inner"))
+
+	_ = c.Set(context.Background(), "a", 1)
+
+	if v, err := c.Get(context.Background(), "a"); err != nil || v != 1 {
+		t.Fatalf("Get: want 1, got %d, %v", v, err)
+	}
+
+	if want := []string{"outer", "inner"}; !slices.Equal(log, want) {
+		t.Errorf("order: want %v, got %v", want, log)
+	}
+}
+
+func TestWithMiddlewareNone(t *testing.T) {
+	t.Parallel()
+
+	inner := cachetest.NewFake[string, int]()
+
+	if c := cache.WithMiddleware[string, int](inner); c != inner {
+		t.Error("WithMiddleware without middleware did not return the cache itself")
+	}
+}
+
+var errForbidden = errors.New("forbidden key")
+
+// guard rejects writes to keys outside of a namespace.
+type guard struct {
+	cache.Interface[string, string]
+}
+
+func (g guard) Set(ctx context.Context, key, value string) error {
+	if !strings.HasPrefix(key, "app:") {
+		return fmt.Errorf("%w: %s", errForbidden, key)
+	}
+
+	return g.Interface.Set(ctx, key, value)
+}
+
+func ExampleWithMiddleware() {
+	c := cache.WithMiddleware[string, string](
+		cachetest.NewFake[string, string](),
+		func(next cache.Interface[string, string]) cache.Interface[string, string] {
+			return guard{next}
+		},
+	)
+
+	fmt.Println(c.Set(context.Background(), "app:a", "1"))
+	fmt.Println(c.Set(context.Background(), "other:a", "1"))
+	// Output:
+	// <nil>
+	// forbidden key: other:a
+}
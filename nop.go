@@ -0,0 +1,47 @@
+package cache
+
+import "context"
+
+// NopCache is a cache that stores nothing. It stands in for a real cache
+// where caching is disabled, without changing the code that uses it.
+//
+// The zero value is ready to use.
+type NopCache[K comparable, V any] struct {
+	// Getter, if not nil, is called by every Get to load the value, which is
+	// returned without being stored.
+	Getter Getter[K, V]
+}
+
+var _ Interface[string, any] = (*NopCache[string, any])(nil)
+
+// Nop returns a NopCache without a getter.
+func Nop[K comparable, V any]() *NopCache[K, V] {
+	return &NopCache[K, V]{}
+}
+
+// Get returns the value loaded by the getter, or ErrNotFound if there is no
+// getter.
+func (c *NopCache[K, V]) Get(ctx context.Context, key K) (V, error) {
+	if c.Getter == nil {
+		var zero V
+
+		return zero, ErrNotFound
+	}
+
+	return c.Getter(ctx, key)
+}
+
+// Set discards value.
+func (*NopCache[K, V]) Set(context.Context, K, V) error {
+	return nil
+}
+
+// Delete does nothing.
+func (*NopCache[K, V]) Delete(context.Context, K) error {
+	return nil
+}
+
+// Len returns 0.
+func (*NopCache[K, V]) Len() int {
+	return 0
+}
@@ -0,0 +1,65 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+
+	"go.expect.digital/cache"
+)
+
+func TestNop(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	c := cache.Nop[string, int]()
+
+	if err := c.Set(ctx, "a", 1); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	if v, err := c.Get(ctx, "a"); !errors.Is(err, cache.ErrNotFound) || v != 0 {
+		t.Fatalf("Get: want 0, ErrNotFound, got %d, %v", v, err)
+	}
+
+	if err := c.Delete(ctx, "a"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	if c.Len() != 0 {
+		t.Errorf("Len: want 0, got %d", c.Len())
+	}
+}
+
+func TestNopGetter(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	calls := 0
+
+	c := &cache.NopCache[string, int]{
+		Getter: func(_ context.Context, key string) (int, error) {
+			calls++
+
+			return strconv.Atoi(key)
+		},
+	}
+
+	_ = c.Set(ctx, "1", 100)
+
+	// Every Get loads, ignoring what was set.
+	for range 2 {
+		if v, err := c.Get(ctx, "1"); err != nil || v != 1 {
+			t.Fatalf("Get: want 1, got %d, %v", v, err)
+		}
+	}
+
+	if calls != 2 {
+		t.Errorf("getter calls: want 2, got %d", calls)
+	}
+
+	if _, err := c.Get(ctx, "x"); !errors.Is(err, strconv.ErrSyntax) {
+		t.Errorf("Get with failing getter: want ErrSyntax, got %v", err)
+	}
+}
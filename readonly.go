@@ -0,0 +1,44 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+)
+
+// ReadOnlyError is returned by a read-only view for an attempt to modify the
+// cache.
+type ReadOnlyError struct {
+	Op  string // "Set" or "Delete"
+	Key any
+}
+
+func (e *ReadOnlyError) Error() string {
+	return fmt.Sprintf("cache: %s %v: read-only view", e.Op, e.Key)
+}
+
+// ReadOnly returns a view of c for code that must not modify it, such as
+// plugins. Get and Len are forwarded to c; Set and Delete return a
+// *ReadOnlyError and leave c unchanged. The view does not expose c itself.
+func ReadOnly[K comparable, V any](c Interface[K, V]) Interface[K, V] {
+	return readOnly[K, V]{c}
+}
+
+type readOnly[K comparable, V any] struct {
+	c Interface[K, V]
+}
+
+func (r readOnly[K, V]) Get(ctx context.Context, key K) (V, error) {
+	return r.c.Get(ctx, key)
+}
+
+func (readOnly[K, V]) Set(_ context.Context, key K, _ V) error {
+	return &ReadOnlyError{Op: "Set", Key: key}
+}
+
+func (readOnly[K, V]) Delete(_ context.Context, key K) error {
+	return &ReadOnlyError{Op: "Delete", Key: key}
+}
+
+func (r readOnly[K, V]) Len() int {
+	return r.c.Len()
+}
@@ -0,0 +1,48 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.expect.digital/cache"
+	"go.expect.digital/cache/cachetest"
+)
+
+func TestReadOnly(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	inner := cachetest.NewFake[string, int]()
+	_ = inner.Set(ctx, "a", 1)
+
+	view := cache.ReadOnly[string, int](inner)
+
+	if v, err := view.Get(ctx, "a"); err != nil || v != 1 {
+		t.Fatalf("Get: want 1, got %d, %v", v, err)
+	}
+
+	if view.Len() != 1 {
+		t.Fatalf("Len: want 1, got %d", view.Len())
+	}
+
+	var roErr *cache.ReadOnlyError
+
+	if err := view.Set(ctx, "a", 2); !errors.As(err, &roErr) || roErr.Op != "Set" || roErr.Key != "a" {
+		t.Fatalf("Set: want ReadOnlyError{Set, a}, got %v", err)
+	}
+
+	if err := view.Delete(ctx, "a"); !errors.As(err, &roErr) || roErr.Op != "Delete" || roErr.Key != "a" {
+		t.Fatalf("Delete: want ReadOnlyError{Delete, a}, got %v", err)
+	}
+
+	if v, _ := inner.Get(ctx, "a"); v != 1 {
+		t.Errorf("inner value: want 1, got %d", v)
+	}
+
+	// The view must not let callers reach the TTL setter of the inner cache.
+	if _, ok := view.(cache.TTLSetter[string, int]); ok {
+		t.Error("read-only view implements TTLSetter")
+	}
+
+}
@@ -0,0 +1,236 @@
+// Package sketch implements a count-min sketch with 4-bit counters and
+// periodic aging, suitable as the frequency estimator of a TinyLFU admission
+// policy.
+//
+// # Error bounds
+//
+// The sketch has 4 rows of w counters each. After N increments, an estimate
+// never undercounts (until aging or saturation) and overcounts by more than
+// e/w·N with probability at most e⁻⁴ ≈ 1.8%. Counters saturate at 15, so the
+// sketch distinguishes popularity rather than measuring exact counts.
+//
+// # Aging
+//
+// Once the number of increments reaches the sample size (10·w by default),
+// every counter is halved. This keeps estimates biased towards recent
+// activity and bounds N in the error formula above to roughly the sample size.
+package sketch
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+)
+
+const (
+	depth = 4
+
+	// counterMax is the largest value a 4-bit counter holds.
+	counterMax = 15
+
+	// countersPerWord is the number of 4-bit counters packed in a uint64.
+	countersPerWord = 16
+
+	// halveMask clears the bit shifted into each counter from its neighbour
+	// when a word is shifted right by one.
+	halveMask = 0x7777777777777777
+
+	formatVersion = 1
+)
+
+// seeds decorrelate the row indexes derived from a single key hash.
+var seeds = [depth]uint64{
+	0xc3a5c85c97cb3127,
+	0xb492b66fbe98f273,
+	0x9ae16a3b2f90404f,
+	0xcbf29ce484222325,
+}
+
+// ErrInvalidData is returned by UnmarshalBinary for malformed input.
+var ErrInvalidData = errors.New("sketch: invalid data")
+
+// CountMin is a count-min sketch of 4-bit counters keyed by 64-bit hashes.
+//
+// Callers hash their keys (with any well-distributed 64-bit hash) and pass the
+// hash to Increment and Estimate. A CountMin is not safe for concurrent use.
+//
+// The zero value is not usable; create sketches with New or decode them with
+// UnmarshalBinary.
+type CountMin struct {
+	rows       [depth][]uint64
+	mask       uint64
+	additions  int
+	sampleSize int
+}
+
+// New returns a sketch with at least width counters per row. The width is
+// rounded up to a power of two, and to no fewer than 16 counters. A good
+// width is the number of distinct keys to be tracked, e.g. the capacity of the
+// cache using the sketch.
+func New(width int) *CountMin {
+	width = max(width, countersPerWord)
+	width = 1 << bits.Len(uint(width-1))
+
+	s := &CountMin{
+		mask:       uint64(width - 1),
+		sampleSize: 10 * width,
+	}
+
+	for i := range s.rows {
+		s.rows[i] = make([]uint64, width/countersPerWord)
+	}
+
+	return s
+}
+
+// Width returns the number of counters per row.
+func (s *CountMin) Width() int {
+	return int(s.mask + 1)
+}
+
+// Increment increments the counters of h, aging the sketch when the sample
+// size is reached.
+func (s *CountMin) Increment(h uint64) {
+	for i := range s.rows {
+		word, shift := s.position(i, h)
+
+		if (s.rows[i][word]>>shift)&counterMax < counterMax {
+			s.rows[i][word] += 1 << shift
+		}
+	}
+
+	s.additions++
+	if s.additions >= s.sampleSize {
+		s.Reset()
+	}
+}
+
+// Estimate returns the estimated frequency of h, in the range [0, 15].
+func (s *CountMin) Estimate(h uint64) uint8 {
+	estimate := uint64(counterMax)
+
+	for i := range s.rows {
+		word, shift := s.position(i, h)
+		estimate = min(estimate, (s.rows[i][word]>>shift)&counterMax)
+	}
+
+	return uint8(estimate)
+}
+
+// Reset ages the sketch by halving every counter. It is called automatically
+// by Increment and rarely needs to be called directly.
+func (s *CountMin) Reset() {
+	for i := range s.rows {
+		for j := range s.rows[i] {
+			s.rows[i][j] = (s.rows[i][j] >> 1) & halveMask
+		}
+	}
+
+	s.additions /= 2
+}
+
+// Clear zeroes every counter.
+func (s *CountMin) Clear() {
+	for i := range s.rows {
+		clear(s.rows[i])
+	}
+
+	s.additions = 0
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler, e.g. for including the
+// sketch in a cache snapshot.
+func (s *CountMin) MarshalBinary() ([]byte, error) {
+	words := len(s.rows[0])
+	data := make([]byte, 0, 1+3*binary.MaxVarintLen64+depth*words*8)
+
+	data = append(data, formatVersion)
+	data = binary.AppendUvarint(data, uint64(s.Width()))
+	data = binary.AppendUvarint(data, uint64(s.additions))
+	data = binary.AppendUvarint(data, uint64(s.sampleSize))
+
+	for i := range s.rows {
+		for _, w := range s.rows[i] {
+			data = binary.LittleEndian.AppendUint64(data, w)
+		}
+	}
+
+	return data, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler. It replaces the
+// state of s, including its width, with the decoded sketch.
+func (s *CountMin) UnmarshalBinary(data []byte) error {
+	if len(data) == 0 || data[0] != formatVersion {
+		return fmt.Errorf("%w: unsupported format version", ErrInvalidData)
+	}
+
+	data = data[1:]
+
+	var header [3]uint64
+
+	for i := range header {
+		v, n := binary.Uvarint(data)
+		if n <= 0 {
+			return fmt.Errorf("%w: truncated header", ErrInvalidData)
+		}
+
+		header[i], data = v, data[n:]
+	}
+
+	width, additions, sampleSize := header[0], header[1], header[2]
+
+	if width < countersPerWord || width&(width-1) != 0 {
+		return fmt.Errorf("%w: width %d is not a power of two >= %d", ErrInvalidData, width, countersPerWord)
+	}
+
+	// Bound words by the payload before multiplying, so a huge width cannot
+	// overflow the expected length.
+	words := width / countersPerWord
+	if words > uint64(len(data))/(depth*8) || uint64(len(data)) != depth*words*8 {
+		return fmt.Errorf("%w: got %d counter bytes for width %d", ErrInvalidData, len(data), width)
+	}
+
+	if sampleSize > math.MaxInt {
+		return fmt.Errorf("%w: sample size %d overflows int", ErrInvalidData, sampleSize)
+	}
+
+	if sampleSize == 0 || additions >= sampleSize {
+		return fmt.Errorf("%w: additions %d out of range for sample size %d", ErrInvalidData, additions, sampleSize)
+	}
+
+	s.mask = width - 1
+	s.additions = int(additions)
+	s.sampleSize = int(sampleSize)
+
+	for i := range s.rows {
+		s.rows[i] = make([]uint64, words)
+
+		for j := range s.rows[i] {
+			s.rows[i][j] = binary.LittleEndian.Uint64(data)
+			data = data[8:]
+		}
+	}
+
+	return nil
+}
+
+// position returns the word index and bit shift of the counter for h in row i.
+func (s *CountMin) position(i int, h uint64) (int, uint) {
+	idx := mix(h^seeds[i]) & s.mask
+
+	return int(idx / countersPerWord), uint(idx%countersPerWord) * 4
+}
+
+// mix is the splitmix64 finalizer.
+func mix(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+
+	return x
+}
@@ -0,0 +1,274 @@
+package sketch
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+// hash stands in for a well-distributed 64-bit key hash.
+func hash(i int) uint64 {
+	return mix(uint64(i) + 0x9e3779b97f4a7c15)
+}
+
+// counters returns the sum of all counters in the sketch.
+func counters(s *CountMin) int {
+	sum := 0
+
+	for i := range s.rows {
+		for _, w := range s.rows[i] {
+			for ; w != 0; w >>= 4 {
+				sum += int(w & counterMax)
+			}
+		}
+	}
+
+	return sum
+}
+
+func TestNewWidth(t *testing.T) {
+	t.Parallel()
+
+	for width, want := range map[int]int{-1: 16, 0: 16, 1: 16, 16: 16, 17: 32, 100: 128, 1024: 1024} {
+		if got := New(width).Width(); got != want {
+			t.Errorf("New(%d).Width(): want %d, got %d", width, want, got)
+		}
+	}
+}
+
+func TestEstimate(t *testing.T) {
+	t.Parallel()
+
+	s := New(1024)
+
+	for i := range 10 {
+		for range i {
+			s.Increment(hash(i))
+		}
+	}
+
+	for i := range 10 {
+		if got := s.Estimate(hash(i)); got != uint8(i) {
+			t.Errorf("Estimate(key %d): want %d, got %d", i, i, got)
+		}
+	}
+
+	if got := s.Estimate(hash(1000)); got != 0 {
+		t.Errorf("Estimate(unseen key): want 0, got %d", got)
+	}
+}
+
+func TestErrorBound(t *testing.T) {
+	t.Parallel()
+
+	const (
+		width = 1024
+		keys  = 1000
+	)
+
+	s := New(width)
+	rnd := rand.New(rand.NewPCG(1, 2))
+	counts := make([]int, keys)
+	n := 0
+
+	// Stay below the sample size so no aging happens, and below saturation
+	// so estimates are not capped.
+	for i := range keys {
+		counts[i] = rnd.IntN(3)
+		for range counts[i] {
+			s.Increment(hash(i))
+		}
+
+		n += counts[i]
+	}
+
+	if n >= s.sampleSize {
+		t.Fatalf("test increments %d reach sample size %d", n, s.sampleSize)
+	}
+
+	epsilon := math.E / width * float64(n)
+	exceeded := 0
+
+	for i, count := range counts {
+		got := int(s.Estimate(hash(i)))
+		if got < count {
+			t.Fatalf("key %d: estimate %d below true count %d", i, got, count)
+		}
+
+		if float64(got-count) > epsilon {
+			exceeded++
+		}
+	}
+
+	// The documented probability is e⁻⁴ ≈ 1.8%; allow for sampling noise.
+	if rate := float64(exceeded) / keys; rate > 0.03 {
+		t.Errorf("%.1f%% of estimates exceed the error bound %.1f, want at most ~1.8%%", 100*rate, epsilon)
+	}
+}
+
+func TestSaturation(t *testing.T) {
+	t.Parallel()
+
+	s := New(1024)
+
+	for range 100 {
+		s.Increment(hash(1))
+	}
+
+	if got := s.Estimate(hash(1)); got != counterMax {
+		t.Errorf("Estimate: want %d, got %d", counterMax, got)
+	}
+
+	// A counter that overflowed would carry into its neighbour.
+	if got := counters(s); got != depth*counterMax {
+		t.Errorf("sum of counters: want %d, got %d", depth*counterMax, got)
+	}
+}
+
+func TestAgingAtSampleSize(t *testing.T) {
+	t.Parallel()
+
+	s := New(16)
+
+	for range s.sampleSize - 1 {
+		s.Increment(hash(1))
+	}
+
+	if got := s.Estimate(hash(1)); got != counterMax {
+		t.Fatalf("before aging: want %d, got %d", counterMax, got)
+	}
+
+	s.Increment(hash(1))
+
+	if got := s.Estimate(hash(1)); got != counterMax/2 {
+		t.Errorf("after aging: want %d, got %d", counterMax/2, got)
+	}
+
+	if s.additions != s.sampleSize/2 {
+		t.Errorf("additions after aging: want %d, got %d", s.sampleSize/2, s.additions)
+	}
+}
+
+func TestResetHalvesEachCounter(t *testing.T) {
+	t.Parallel()
+
+	s := New(16)
+	rnd := rand.New(rand.NewPCG(3, 4))
+
+	for i := range s.rows {
+		s.rows[i][0] = rnd.Uint64()
+	}
+
+	before := s.rows
+
+	for i := range before {
+		before[i] = append([]uint64(nil), s.rows[i]...)
+	}
+
+	s.Reset()
+
+	for i := range s.rows {
+		for c := range countersPerWord {
+			shift := uint(c * 4)
+			want := (before[i][0] >> shift & counterMax) / 2
+
+			if got := s.rows[i][0] >> shift & counterMax; got != want {
+				t.Errorf("row %d counter %d: want %d, got %d", i, c, want, got)
+			}
+		}
+	}
+}
+
+func TestClear(t *testing.T) {
+	t.Parallel()
+
+	s := New(16)
+	s.Increment(hash(1))
+	s.Clear()
+
+	if counters(s) != 0 || s.additions != 0 {
+		t.Error("Clear left counters or additions")
+	}
+}
+
+func TestMarshalRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	s := New(64)
+	for i := range 300 {
+		s.Increment(hash(i % 37))
+	}
+
+	data, err := s.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got CountMin
+	if err := got.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Width() != s.Width() || got.additions != s.additions || got.sampleSize != s.sampleSize {
+		t.Fatalf("header mismatch: want %d/%d/%d, got %d/%d/%d",
+			s.Width(), s.additions, s.sampleSize, got.Width(), got.additions, got.sampleSize)
+	}
+
+	for i := range 100 {
+		if want, got := s.Estimate(hash(i)), got.Estimate(hash(i)); want != got {
+			t.Errorf("key %d: want %d, got %d", i, want, got)
+		}
+	}
+}
+
+func TestUnmarshalInvalid(t *testing.T) {
+	t.Parallel()
+
+	valid, err := New(16).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encode := func(width, additions, sampleSize uint64, payload int) []byte {
+		data := []byte{formatVersion}
+		data = binary.AppendUvarint(data, width)
+		data = binary.AppendUvarint(data, additions)
+		data = binary.AppendUvarint(data, sampleSize)
+
+		return append(data, make([]byte, payload)...)
+	}
+
+	tests := map[string][]byte{
+		"empty":                    nil,
+		"version":                  append([]byte{formatVersion + 1}, valid[1:]...),
+		"truncated header":         valid[:2],
+		"truncated counters":       valid[:len(valid)-1],
+		"trailing bytes":           append(append([]byte(nil), valid...), 0),
+		"width not power of two":   encode(24, 0, 240, depth*2*8),
+		"width too small":          encode(8, 0, 80, 0),
+		"width overflows length":   encode(1<<63, 0, 160, 0),
+		"width exceeds payload":    encode(1<<40, 0, 160, depth*8),
+		"sample size zero":         encode(16, 0, 0, depth*8),
+		"additions at sample size": encode(16, 160, 160, depth*8),
+		"sample size overflows":    encode(16, 0, math.MaxUint64, depth*8),
+	}
+
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			s := New(32)
+			s.Increment(hash(1))
+
+			if err := s.UnmarshalBinary(data); !errors.Is(err, ErrInvalidData) {
+				t.Fatalf("want ErrInvalidData, got %v", err)
+			}
+
+			if s.Width() != 32 || s.Estimate(hash(1)) != 1 {
+				t.Error("failed UnmarshalBinary modified the sketch")
+			}
+		})
+	}
+}
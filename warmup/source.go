@@ -0,0 +1,98 @@
+package warmup
+
+import (
+	"encoding/csv"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+)
+
+// ErrSkip is returned by the parse function of CSV to skip a row, e.g. a
+// header.
+var ErrSkip = errors.New("warmup: skip row")
+
+// JSON returns the records of r, a stream of JSON objects of the form
+// {"key": ..., "value": ...}, such as a JSON Lines file. The records are read
+// as the sequence is iterated, so it can be iterated only once.
+func JSON[K comparable, V any](r io.Reader) iter.Seq2[Record[K, V], error] {
+	return decode[K, V](json.NewDecoder(r), "json")
+}
+
+// Gob returns the records of r, a stream of Record values written by a single
+// gob.Encoder. The records are read as the sequence is iterated, so it can be
+// iterated only once.
+func Gob[K comparable, V any](r io.Reader) iter.Seq2[Record[K, V], error] {
+	return decode[K, V](gob.NewDecoder(r), "gob")
+}
+
+// CSV returns the records of r, a CSV file, converting each row with parse.
+// Rows for which parse returns ErrSkip are skipped; any other error of parse
+// ends the sequence. Every row must have as many fields as the first. The
+// records are read as the sequence is iterated, so it can be iterated only
+// once.
+func CSV[K comparable, V any](r io.Reader, parse func(row []string) (Record[K, V], error)) iter.Seq2[Record[K, V], error] {
+	return func(yield func(Record[K, V], error) bool) {
+		cr := csv.NewReader(r)
+
+		for {
+			row, err := cr.Read()
+			if errors.Is(err, io.EOF) {
+				return
+			}
+
+			if err != nil {
+				yield(Record[K, V]{}, fmt.Errorf("warmup: csv: %w", err))
+
+				return
+			}
+
+			rec, err := parse(row)
+			if errors.Is(err, ErrSkip) {
+				continue
+			}
+
+			if err != nil {
+				line, _ := cr.FieldPos(0)
+				yield(Record[K, V]{}, fmt.Errorf("warmup: csv line %d: %w", line, err))
+
+				return
+			}
+
+			if !yield(rec, nil) {
+				return
+			}
+		}
+	}
+}
+
+// decoder is implemented by json.Decoder and gob.Decoder.
+type decoder interface {
+	Decode(v any) error
+}
+
+// decode returns the records decoded by dec, numbering them in errors.
+func decode[K comparable, V any](dec decoder, format string) iter.Seq2[Record[K, V], error] {
+	return func(yield func(Record[K, V], error) bool) {
+		for n := 1; ; n++ {
+			var rec Record[K, V]
+
+			err := dec.Decode(&rec)
+			if errors.Is(err, io.EOF) {
+				return
+			}
+
+			if err != nil {
+				yield(rec, fmt.Errorf("warmup: %s record %d: %w", format, n, err))
+
+				return
+			}
+
+			if !yield(rec, nil) {
+				return
+			}
+		}
+	}
+}
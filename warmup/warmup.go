@@ -0,0 +1,156 @@
+// Package warmup seeds a cache from a stream of key/value records, such as a
+// nightly export of the source of truth, before it starts serving.
+//
+// Records come from an iter.Seq2 of records and errors, so any source can be
+// plugged in; JSON, Gob and CSV read the common file formats. Load stores
+// them through cache.Interface with bounded concurrency, reporting progress
+// and stopping at the first error.
+//
+// Unlike a snapshot, a warm-up file is not tied to a cache implementation or
+// its internal state: it is plain data, and a warmed-up cache is
+// indistinguishable from one filled by regular Sets.
+package warmup
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"sync"
+	"time"
+
+	"go.expect.digital/cache"
+)
+
+// DefaultConcurrency is the number of concurrent Sets when WithConcurrency
+// is not given.
+const DefaultConcurrency = 8
+
+// Record is a key/value pair to store in the cache.
+type Record[K comparable, V any] struct {
+	Key   K `json:"key"`
+	Value V `json:"value"`
+}
+
+type config struct {
+	progress    func(n int)
+	every       int
+	concurrency int
+	ttl         time.Duration
+}
+
+// Option configures Load.
+type Option func(*config)
+
+// WithConcurrency sets the maximum number of concurrent Sets. Values below 1
+// are ignored.
+func WithConcurrency(n int) Option {
+	return func(c *config) {
+		if n > 0 {
+			c.concurrency = n
+		}
+	}
+}
+
+// WithProgress makes Load call fn with the number of records stored so far
+// every time another every records have been stored. Calls are serialized.
+// An every below 1 or a nil fn disables progress reporting.
+func WithProgress(every int, fn func(n int)) Option {
+	return func(c *config) {
+		if every > 0 && fn != nil {
+			c.every, c.progress = every, fn
+		}
+	}
+}
+
+// WithTTL stores the records with a time to live of ttl if the cache
+// implements cache.TTLSetter. A ttl of zero or less, the default, stores them
+// without expiration.
+func WithTTL(ttl time.Duration) Option {
+	return func(c *config) {
+		c.ttl = ttl
+	}
+}
+
+// Load stores records in c and returns the number stored.
+//
+// It stops at the first error, from reading records or from storing one, and
+// returns it along with the count of records stored until then; records
+// stored concurrently with the failure may or may not be counted. Load also
+// stops with ctx.Err() if ctx is done. Records are stored in no particular
+// order, so a key repeated in records may end up with any of its values.
+func Load[K comparable, V any](
+	ctx context.Context,
+	c cache.Interface[K, V],
+	records iter.Seq2[Record[K, V], error],
+	opts ...Option,
+) (int, error) {
+	cfg := config{concurrency: DefaultConcurrency}
+
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	var (
+		jobs   = make(chan Record[K, V])
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		stored int
+	)
+
+	for range cfg.concurrency {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for rec := range jobs {
+				if err := set(ctx, c, rec, cfg.ttl); err != nil {
+					cancel(fmt.Errorf("warmup: store %v: %w", rec.Key, err))
+
+					continue
+				}
+
+				mu.Lock()
+				stored++
+
+				if cfg.every > 0 && stored%cfg.every == 0 {
+					cfg.progress(stored)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for rec, err := range records {
+		if err != nil {
+			cancel(err)
+
+			break
+		}
+
+		select {
+		case jobs <- rec:
+			continue
+		case <-ctx.Done():
+		}
+
+		break
+	}
+
+	close(jobs)
+	wg.Wait()
+
+	return stored, context.Cause(ctx)
+}
+
+// set stores rec in c, with ttl if c supports it.
+func set[K comparable, V any](ctx context.Context, c cache.Interface[K, V], rec Record[K, V], ttl time.Duration) error {
+	if s, ok := c.(cache.TTLSetter[K, V]); ok && ttl > 0 {
+		return s.SetWithTTL(ctx, rec.Key, rec.Value, ttl)
+	}
+
+	return c.Set(ctx, rec.Key, rec.Value)
+}
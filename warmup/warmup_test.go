@@ -0,0 +1,244 @@
+package warmup_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"iter"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.expect.digital/cache"
+	"go.expect.digital/cache/cachetest"
+	"go.expect.digital/cache/warmup"
+)
+
+var errBoom = errors.New("boom")
+
+// records returns n records mapping "key<i>" to i.
+func records(n int) iter.Seq2[warmup.Record[string, int], error] {
+	return func(yield func(warmup.Record[string, int], error) bool) {
+		for i := range n {
+			if !yield(warmup.Record[string, int]{Key: "key" + strconv.Itoa(i), Value: i}, nil) {
+				return
+			}
+		}
+	}
+}
+
+func checkStored(t *testing.T, c cache.Interface[string, int], n int) {
+	t.Helper()
+
+	if c.Len() != n {
+		t.Fatalf("Len: want %d, got %d", n, c.Len())
+	}
+
+	for i := range n {
+		key := "key" + strconv.Itoa(i)
+		if v, err := c.Get(context.Background(), key); err != nil || v != i {
+			t.Errorf("Get(%s): want %d, got %d, %v", key, i, v, err)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+
+	c := cachetest.NewFake[string, int]()
+
+	var (
+		progress []int
+		mu       sync.Mutex
+	)
+
+	n, err := warmup.Load(context.Background(), c, records(100), warmup.WithProgress(30, func(n int) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		progress = append(progress, n)
+	}))
+	if err != nil || n != 100 {
+		t.Fatalf("Load: want 100 records, got %d, %v", n, err)
+	}
+
+	checkStored(t, c, 100)
+
+	if !slices.Equal(progress, []int{30, 60, 90}) {
+		t.Errorf("progress: want [30 60 90], got %v", progress)
+	}
+}
+
+// gauge is a cache that tracks the number of concurrent Sets.
+type gauge struct {
+	*cachetest.Fake[string, int]
+	active, peak atomic.Int32
+}
+
+func (g *gauge) Set(ctx context.Context, key string, value int) error {
+	n := g.active.Add(1)
+	defer g.active.Add(-1)
+
+	for {
+		p := g.peak.Load()
+		if n <= p || g.peak.CompareAndSwap(p, n) {
+			break
+		}
+	}
+
+	time.Sleep(time.Millisecond)
+
+	return g.Fake.Set(ctx, key, value)
+}
+
+func TestLoadConcurrency(t *testing.T) {
+	t.Parallel()
+
+	for _, limit := range []int{1, 4} {
+		g := &gauge{Fake: cachetest.NewFake[string, int]()}
+
+		if _, err := warmup.Load(context.Background(), g, records(40), warmup.WithConcurrency(limit)); err != nil {
+			t.Fatal(err)
+		}
+
+		if p := g.peak.Load(); p > int32(limit) {
+			t.Errorf("WithConcurrency(%d): want at most %d concurrent Sets, got %d", limit, limit, p)
+		}
+	}
+}
+
+func TestLoadStoreError(t *testing.T) {
+	t.Parallel()
+
+	c := cachetest.NewFake[string, int]()
+	c.FailOn(cachetest.OpSet, "key5", errBoom)
+
+	n, err := warmup.Load(context.Background(), c, records(100), warmup.WithConcurrency(1))
+	if !errors.Is(err, errBoom) || !strings.Contains(err.Error(), "key5") {
+		t.Fatalf("Load: want errBoom naming key5, got %v", err)
+	}
+
+	// Nothing is stored past the failure.
+	if n != 5 || c.Len() != 5 {
+		t.Errorf("want 5 records stored and counted, got %d and %d", c.Len(), n)
+	}
+}
+
+func TestLoadSourceError(t *testing.T) {
+	t.Parallel()
+
+	c := cachetest.NewFake[string, int]()
+	src := warmup.JSON[string, int](strings.NewReader(`{"key": "a", "value": 1}` + "\n" + `{"key": "b", "value": "two"}`))
+
+	n, err := warmup.Load(context.Background(), c, src)
+	if err == nil || !strings.Contains(err.Error(), "record 2") {
+		t.Fatalf("Load: want an error for record 2, got %v", err)
+	}
+
+	if n != 1 {
+		t.Errorf("records stored: want 1, got %d", n)
+	}
+}
+
+func TestLoadContext(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := warmup.Load(ctx, cachetest.NewFake[string, int](), records(10)); !errors.Is(err, context.Canceled) {
+		t.Errorf("Load: want Canceled, got %v", err)
+	}
+}
+
+func TestLoadTTL(t *testing.T) {
+	t.Parallel()
+
+	c := cachetest.NewFake[string, int]()
+
+	if _, err := warmup.Load(context.Background(), c, records(10), warmup.WithTTL(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	c.Advance(time.Hour)
+
+	if c.Len() != 0 {
+		t.Errorf("Len after TTL: want 0, got %d", c.Len())
+	}
+}
+
+func TestJSON(t *testing.T) {
+	t.Parallel()
+
+	var b strings.Builder
+
+	for i := range 10 {
+		fmt.Fprintf(&b, "{\"key\": \"key%d\", \"value\": %d}\n", i, i)
+	}
+
+	c := cachetest.NewFake[string, int]()
+
+	if _, err := warmup.Load(context.Background(), c, warmup.JSON[string, int](strings.NewReader(b.String()))); err != nil {
+		t.Fatal(err)
+	}
+
+	checkStored(t, c, 10)
+}
+
+func TestGob(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	enc := gob.NewEncoder(&buf)
+
+	for rec := range records(10) {
+		if err := enc.Encode(rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c := cachetest.NewFake[string, int]()
+
+	if _, err := warmup.Load(context.Background(), c, warmup.Gob[string, int](&buf)); err != nil {
+		t.Fatal(err)
+	}
+
+	checkStored(t, c, 10)
+}
+
+func TestCSV(t *testing.T) {
+	t.Parallel()
+
+	parse := func(row []string) (warmup.Record[string, int], error) {
+		if row[0] == "key" {
+			return warmup.Record[string, int]{}, warmup.ErrSkip
+		}
+
+		v, err := strconv.Atoi(row[1])
+
+		return warmup.Record[string, int]{Key: row[0], Value: v}, err
+	}
+
+	c := cachetest.NewFake[string, int]()
+	src := warmup.CSV(strings.NewReader("key,value\nkey0,0\nkey1,1\nkey2,2\n"), parse)
+
+	if _, err := warmup.Load(context.Background(), c, src); err != nil {
+		t.Fatal(err)
+	}
+
+	checkStored(t, c, 3)
+
+	// Parse errors name the line.
+	src = warmup.CSV(strings.NewReader("key,value\nkey0,0\nkey1,one\n"), parse)
+
+	if _, err := warmup.Load(context.Background(), cachetest.NewFake[string, int](), src); err == nil || !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("Load: want an error for line 3, got %v", err)
+	}
+}